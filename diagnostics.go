@@ -0,0 +1,314 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/MikL9/observability/hide"
+	"github.com/MikL9/observability/logger"
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// CheckFunc проверка состояния сервиса. Возвращает ошибку, если сервис не здоров или не готов
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// DiagnosticsHandler обработчик служебных эндпоинтов:
+// /metrics, /healthz, /readyz, /debug/pprof, /debug/loglevel и /debug/config
+type DiagnosticsHandler struct {
+	mux          *http.ServeMux
+	gatherer     prometheus.Gatherer
+	checkTimeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+}
+
+type DiagnosticsOption func(h *DiagnosticsHandler)
+
+// WithHealthCheck добавляет проверку в /healthz
+func WithHealthCheck(name string, fn CheckFunc) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.AddHealthCheck(name, fn)
+	}
+}
+
+// WithReadinessCheck добавляет проверку в /readyz
+func WithReadinessCheck(name string, fn CheckFunc) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.AddReadinessCheck(name, fn)
+	}
+}
+
+// WithCheckTimeout задает таймаут одной проверки, по умолчанию 5 секунд
+func WithCheckTimeout(timeout time.Duration) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.checkTimeout = timeout
+	}
+}
+
+// WithGatherer задает источник метрик для /metrics. По умолчанию используется
+// registerer из WithPrometheus, если он реализует prometheus.Gatherer
+func WithGatherer(gatherer prometheus.Gatherer) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.gatherer = gatherer
+	}
+}
+
+// NewDiagnosticsHandler создает обработчик служебных эндпоинтов. Вызывается после Init
+func NewDiagnosticsHandler(opts ...DiagnosticsOption) *DiagnosticsHandler {
+	h := &DiagnosticsHandler{
+		mux:          http.NewServeMux(),
+		checkTimeout: defaultCheckTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.gatherer == nil {
+		h.gatherer = defaultGatherer()
+	}
+
+	h.mux.Handle("/metrics", promhttp.HandlerFor(h.gatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+	h.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h.serveChecks(w, r, h.checks(false))
+	})
+	h.mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		h.serveChecks(w, r, h.checks(true))
+	})
+	// обработчики net/http/pprof; импорт пакета также регистрирует их в http.DefaultServeMux
+	h.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	h.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	h.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	h.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	h.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	h.mux.HandleFunc("/debug/loglevel", serveLogLevel)
+	h.mux.HandleFunc("/debug/config", serveConfig)
+	return h
+}
+
+func (h *DiagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// AddHealthCheck добавляет проверку в /healthz
+func (h *DiagnosticsHandler) AddHealthCheck(name string, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, check{name: name, fn: fn})
+}
+
+// AddReadinessCheck добавляет проверку в /readyz
+func (h *DiagnosticsHandler) AddReadinessCheck(name string, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, check{name: name, fn: fn})
+}
+
+func (h *DiagnosticsHandler) checks(readiness bool) []check {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if readiness {
+		return append([]check(nil), h.readiness...)
+	}
+	return append([]check(nil), h.liveness...)
+}
+
+type checkResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (h *DiagnosticsHandler) serveChecks(w http.ResponseWriter, r *http.Request, checks []check) {
+	resp := checkResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), h.checkTimeout)
+			defer cancel()
+
+			status := "ok"
+			if err := c.fn(ctx); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if status != "ok" {
+				resp.Status = "fail"
+			}
+			resp.Checks[c.name] = status
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if resp.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, resp)
+}
+
+type logLevelRequest struct {
+	Level   string `json:"level"`
+	Sink    string `json:"sink,omitempty"`
+	Package string `json:"package,omitempty"`
+}
+
+type logLevelResponse struct {
+	Level    string            `json:"level"`
+	Sinks    map[string]string `json:"sinks,omitempty"`
+	Packages map[string]string `json:"packages,omitempty"`
+}
+
+// serveLogLevel возвращает текущие уровни логирования и меняет их через PUT/POST
+// с параметрами level, sink и package или JSON телом {"level": "warn", "sink": "sentry"}.
+// Без sink и package меняется общий уровень. DELETE с package удаляет уровень пакета
+func serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		req, err := parseLogLevelRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch {
+		case req.Sink != "":
+			if err := logger.SetSinkLevel(req.Sink, level); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+		case req.Package != "":
+			logger.SetPackageLevel(req.Package, level)
+		default:
+			logger.SetLevel(level)
+		}
+	case http.MethodDelete:
+		req, err := parseLogLevelRequest(r)
+		if err != nil || req.Package == "" {
+			http.Error(w, "package is required", http.StatusBadRequest)
+			return
+		}
+		logger.ResetPackageLevel(req.Package)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, logLevels())
+}
+
+func parseLogLevelRequest(r *http.Request) (logLevelRequest, error) {
+	query := r.URL.Query()
+	req := logLevelRequest{
+		Level:   query.Get("level"),
+		Sink:    query.Get("sink"),
+		Package: query.Get("package"),
+	}
+	if req.Level != "" || req.Package != "" {
+		return req, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid body: %w", err)
+	}
+	return req, nil
+}
+
+func logLevels() logLevelResponse {
+	resp := logLevelResponse{
+		Level:    logger.GetLevel().String(),
+		Sinks:    make(map[string]string),
+		Packages: make(map[string]string),
+	}
+	for name, level := range logger.SinkLevels() {
+		resp.Sinks[name] = level.String()
+	}
+	for pattern, level := range logger.PackageLevels() {
+		resp.Packages[pattern] = level.String()
+	}
+	return resp
+}
+
+// serveConfig возвращает действующую конфигурацию с маскированием чувствительных полей через hide
+func serveConfig(w http.ResponseWriter, _ *http.Request) {
+	body, err := json.Marshal(Config())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	masked, err := hide.MaskSensitiveJSONFields(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(masked))
+}
+
+// Config возвращает действующую конфигурацию observability без маскирования
+func Config() map[string]any {
+	config := map[string]any{
+		"log_level": logLevels(),
+	}
+	if instance == nil {
+		return config
+	}
+	config["service_id"] = instance.serviceID
+	config["log_format"] = instance.logFormat
+	config["trace_endpoint"] = instance.traceEndpoint
+	config["metrics_provider"] = fmt.Sprintf("%T", GetMetrics())
+	if instance.nativeHistogramFactor > 0 {
+		config["native_histogram_factor"] = instance.nativeHistogramFactor
+	}
+	if instance.resource != nil {
+		attrs := make(map[string]string, instance.resource.Len())
+		for _, kv := range instance.resource.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		config["resource"] = attrs
+	}
+	for name, value := range instance.config {
+		config[name] = value
+	}
+	return config
+}
+
+func defaultGatherer() prometheus.Gatherer {
+	if instance != nil {
+		if gatherer, ok := instance.prometheus.(prometheus.Gatherer); ok {
+			return gatherer
+		}
+	}
+	return prometheus.DefaultGatherer
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,131 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MikL9/observability/hide"
+	"github.com/MikL9/observability/logger"
+)
+
+func TestDiagnosticsHandler(t *testing.T) {
+	// Snapshot восстанавливает и уровни логов, измененные через /debug/loglevel
+	restore := Snapshot()
+	defer restore()
+	hide.SetDefaultConverter(hide.NewConverter(hide.WithFullExcludeRule([]string{"password"})))
+	defer hide.SetDefaultConverter(nil)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, Init("diag",
+		WithPrometheus(reg),
+		WithConfig("db", map[string]string{"host": "localhost", "password": "secret"}),
+	))
+	promauto.With(reg).NewCounter(prometheus.CounterOpts{Name: "diag_requests_total"}).Inc()
+
+	ready := errors.New("not ready")
+	h := NewDiagnosticsHandler(
+		WithHealthCheck("ping", func(context.Context) error { return nil }),
+		WithReadinessCheck("db", func(context.Context) error { return ready }),
+	)
+
+	do := func(method, target string, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("metrics", func(t *testing.T) {
+		rec := do(http.MethodGet, "/metrics", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "diag_requests_total 1")
+	})
+
+	t.Run("checks", func(t *testing.T) {
+		rec := do(http.MethodGet, "/healthz", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok","checks":{"ping":"ok"}}`, rec.Body.String())
+
+		rec = do(http.MethodGet, "/readyz", "")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"fail","checks":{"db":"not ready"}}`, rec.Body.String())
+
+		ready = nil
+		h.AddReadinessCheck("cache", func(context.Context) error { return nil })
+		rec = do(http.MethodGet, "/readyz", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		rec := do(http.MethodGet, "/debug/pprof/", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "goroutine")
+
+		rec = do(http.MethodGet, "/debug/pprof/goroutine?debug=1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "goroutine profile")
+
+		rec = do(http.MethodGet, "/debug/pprof/symbol", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "num_symbols")
+
+		rec = do(http.MethodGet, "/debug/pprof/unknown", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("loglevel", func(t *testing.T) {
+		rec := do(http.MethodPut, "/debug/loglevel?level=warn", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, slog.LevelWarn, logger.GetLevel())
+
+		rec = do(http.MethodPost, "/debug/loglevel", `{"level":"ERROR"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var levels struct {
+			Level string `json:"level"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &levels))
+		assert.Equal(t, "ERROR", levels.Level)
+
+		rec = do(http.MethodPut, "/debug/loglevel?level=verbose", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, slog.LevelError, logger.GetLevel())
+
+		logger.RegisterLevel("diag", slog.LevelInfo)
+		t.Cleanup(func() { logger.UnregisterLevel("diag") })
+		rec = do(http.MethodPut, "/debug/loglevel?sink=diag&level=debug", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, slog.LevelDebug, logger.SinkLevels()["diag"])
+
+		rec = do(http.MethodPut, "/debug/loglevel?sink=unknown&level=debug", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = do(http.MethodPost, "/debug/loglevel", `{"level":"debug","package":"payments/..."}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"payments/...":"DEBUG"`)
+
+		rec = do(http.MethodDelete, "/debug/loglevel?package=payments/...", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, logger.PackageLevels())
+	})
+
+	t.Run("config", func(t *testing.T) {
+		rec := do(http.MethodGet, "/debug/config", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "secret")
+
+		var config map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &config))
+		assert.Equal(t, "diag", config["service_id"])
+		assert.Equal(t, "localhost", config["db"].(map[string]any)["host"])
+	})
+}
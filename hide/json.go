@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -31,9 +32,14 @@ func JSON(key string, val []byte, limit int, textAttr bool) slog.Attr {
 		return slog.String(stringKey, maskVal)
 	}
 
-	args := make([]any, 0, len(mapVal))
-	for key, val := range mapVal {
-		args = append(args, key, val)
+	keys := make([]string, 0, len(mapVal))
+	for key := range mapVal {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]any, 0, len(mapVal)*2)
+	for _, key := range keys {
+		args = append(args, key, mapVal[key])
 	}
 
 	return slog.Group(key, args...)
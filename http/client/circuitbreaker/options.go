@@ -0,0 +1,57 @@
+package circuitbreaker
+
+import (
+	"time"
+
+	"github.com/MikL9/observability/http/client/retry"
+)
+
+type Option func(*Transport)
+
+// WithCheckFailure задает классификацию ошибок. Запрос считается неуспешным,
+// если функция вернула true. По умолчанию используется retry.DefaultRetryPolicy,
+// чтобы breaker и retry одинаково понимали, что такое сбой.
+func WithCheckFailure(f retry.CheckRetry) Option {
+	return func(t *Transport) {
+		t.CheckFailure = f
+	}
+}
+
+// WithKeyFunc задает ключ, по которому ведется отдельный breaker. По умолчанию - хост запроса.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(t *Transport) {
+		t.KeyFunc = f
+	}
+}
+
+// WithConsecutiveFailures открывает breaker после n неуспешных запросов подряд. 0 отключает порог.
+func WithConsecutiveFailures(n int) Option {
+	return func(t *Transport) {
+		t.ConsecutiveFailures = n
+	}
+}
+
+// WithFailureRatio открывает breaker, если доля неуспешных запросов за окно window
+// достигла ratio. Порог применяется только после minRequests запросов в окне.
+func WithFailureRatio(ratio float64, minRequests int, window time.Duration) Option {
+	return func(t *Transport) {
+		t.FailureRatio = ratio
+		t.MinRequests = minRequests
+		t.Window = window
+	}
+}
+
+// WithOpenTimeout задает время, которое breaker проводит в состоянии open перед переходом в half-open.
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(t *Transport) {
+		t.OpenTimeout = timeout
+	}
+}
+
+// WithHalfOpenMaxRequests задает количество пробных запросов в состоянии half-open.
+// Если все они успешны, breaker закрывается.
+func WithHalfOpenMaxRequests(n int) Option {
+	return func(t *Transport) {
+		t.HalfOpenMaxRequests = n
+	}
+}
@@ -0,0 +1,263 @@
+package circuitbreaker
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/http/client/retry"
+	"github.com/MikL9/observability/logger"
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOpenState возвращается, если запрос не был выполнен из-за открытого breaker
+var ErrOpenState = errors.NewConstError("circuit breaker is open")
+
+// timeNow sets the function that returns the current time.
+// This defaults to time.Now. Changes to this should only be done in tests.
+var timeNow = time.Now
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyFunc возвращает ключ, по которому запросы группируются в отдельный breaker
+type KeyFunc func(*http.Request) string
+
+// HostKey ведет отдельный breaker на каждый хост
+func HostKey(r *http.Request) string {
+	return r.URL.Host
+}
+
+func keyBreaker(v string) slog.Attr { return slog.String("circuit_breaker_key", v) }
+
+type breaker struct {
+	state      State
+	generation uint64
+	openedAt   time.Time
+
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+
+	window window
+}
+
+type transition struct {
+	key      string
+	from, to State
+}
+
+type Transport struct {
+	base http.RoundTripper
+
+	CheckFailure retry.CheckRetry
+	KeyFunc      KeyFunc
+
+	ConsecutiveFailures int
+	FailureRatio        float64
+	MinRequests         int
+	Window              time.Duration
+
+	OpenTimeout         time.Duration
+	HalfOpenMaxRequests int
+
+	StateGauge metrics.UpDownCounter
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func NewTransport(base http.RoundTripper, serviceID string, opts ...Option) *Transport {
+	tr := &Transport{
+		base:                base,
+		CheckFailure:        retry.DefaultRetryPolicy,
+		KeyFunc:             HostKey,
+		ConsecutiveFailures: 5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		StateGauge: observability.GetMetrics().UpDownCounter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "circuit_breaker_state",
+			Help:      "Состояние circuit breaker: 0 - closed, 1 - open, 2 - half-open",
+			Labels:    []string{"key"},
+		}),
+		breakers: make(map[string]*breaker),
+	}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key := t.KeyFunc(req)
+
+	generation, ok, changed := t.allow(ctx, key)
+	t.logTransition(ctx, changed)
+	if !ok {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Bool("circuit_breaker.short_circuited", true),
+			attribute.String("circuit_breaker.key", key),
+		)
+		return nil, errors.Wrap(ctx, ErrOpenState, keyBreaker(key))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	failure, _ := t.CheckFailure(ctx, resp, err)
+	t.logTransition(ctx, t.done(ctx, key, generation, failure, ctx.Err() != nil))
+	return resp, err
+}
+
+// State возвращает текущее состояние breaker для ключа
+func (t *Transport) State(key string) State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b, ok := t.breakers[key]; ok {
+		return b.state
+	}
+	return StateClosed
+}
+
+func (t *Transport) getBreaker(ctx context.Context, key string) *breaker {
+	b, ok := t.breakers[key]
+	if !ok {
+		b = &breaker{window: newWindow(t.Window)}
+		t.breakers[key] = b
+		t.StateGauge.Add(ctx, float64(StateClosed), key)
+	}
+	return b
+}
+
+func (t *Transport) allow(ctx context.Context, key string) (uint64, bool, *transition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changed *transition
+	b := t.getBreaker(ctx, key)
+	switch b.state {
+	case StateOpen:
+		if timeNow().Sub(b.openedAt) < t.OpenTimeout {
+			return b.generation, false, nil
+		}
+		changed = t.setState(ctx, key, b, StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.halfOpenInFlight+b.halfOpenSuccesses >= t.HalfOpenMaxRequests {
+			return b.generation, false, changed
+		}
+		b.halfOpenInFlight++
+	}
+	return b.generation, true, changed
+}
+
+func (t *Transport) done(ctx context.Context, key string, generation uint64, failure, canceled bool) *transition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.getBreaker(ctx, key)
+	// состояние поменялось, пока выполнялся запрос - результат уже не актуален
+	if b.generation != generation {
+		return nil
+	}
+
+	switch b.state {
+	case StateClosed:
+		if canceled {
+			return nil
+		}
+		now := timeNow()
+		b.window.add(now, failure)
+		if !failure {
+			b.consecutiveFailures = 0
+			return nil
+		}
+		b.consecutiveFailures++
+		if t.tripped(b, now) {
+			return t.setState(ctx, key, b, StateOpen)
+		}
+	case StateHalfOpen:
+		b.halfOpenInFlight--
+		if canceled {
+			return nil
+		}
+		if failure {
+			return t.setState(ctx, key, b, StateOpen)
+		}
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= t.HalfOpenMaxRequests {
+			return t.setState(ctx, key, b, StateClosed)
+		}
+	}
+	return nil
+}
+
+func (t *Transport) tripped(b *breaker, now time.Time) bool {
+	if t.ConsecutiveFailures > 0 && b.consecutiveFailures >= t.ConsecutiveFailures {
+		return true
+	}
+	if t.FailureRatio <= 0 || t.Window <= 0 {
+		return false
+	}
+	c := b.window.totals(now)
+	if c.requests == 0 || c.requests < t.MinRequests {
+		return false
+	}
+	return float64(c.failures)/float64(c.requests) >= t.FailureRatio
+}
+
+func (t *Transport) setState(ctx context.Context, key string, b *breaker, state State) *transition {
+	changed := &transition{key: key, from: b.state, to: state}
+	// значение gauge меняется на разницу состояний, так как UpDownCounter не умеет Set
+	t.StateGauge.Add(ctx, float64(state-b.state), key)
+
+	b.state = state
+	b.generation++
+	b.consecutiveFailures = 0
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = timeNow()
+	case StateClosed:
+		b.window.reset()
+	}
+	return changed
+}
+
+func (t *Transport) logTransition(ctx context.Context, changed *transition) {
+	if changed == nil {
+		return
+	}
+	logger.Warn(ctx, "circuit breaker state changed",
+		keyBreaker(changed.key),
+		slog.String("from", changed.from.String()),
+		slog.String("to", changed.to.String()),
+	)
+}
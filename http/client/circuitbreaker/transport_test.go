@@ -0,0 +1,121 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/observabilitytest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTransport struct {
+	statuses []int
+	calls    int
+}
+
+func (t *MockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	status := t.statuses[0]
+	if len(t.statuses) > 1 {
+		t.statuses = t.statuses[1:]
+	}
+	t.calls++
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func testInit(t *testing.T) {
+	require.NoError(t, observability.Init("test", observability.WithPrometheus(prometheus.NewRegistry())))
+}
+
+func testClock(t *testing.T) *time.Time {
+	now := time.Date(1999, 12, 31, 23, 59, 57, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+	return &now
+}
+
+func doRequest(t *testing.T, tr *Transport) error {
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	require.NoError(t, err)
+	_, err = tr.RoundTrip(req)
+	return err
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	sink := observabilitytest.New(t)
+	now := testClock(t)
+	m := &MockTransport{statuses: []int{500, 500, 500, 200}}
+	tr := NewTransport(m, "test",
+		WithConsecutiveFailures(3),
+		WithOpenTimeout(time.Second),
+	)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, doRequest(t, tr))
+	}
+	assert.Equal(t, StateOpen, tr.State("example.com"))
+	assert.Equal(t, float64(StateOpen), sink.GaugeValue(t, "http_test_circuit_breaker_state", map[string]string{"key": "example.com"}))
+
+	err := doRequest(t, tr)
+	assert.True(t, errors.Is(err, ErrOpenState))
+	assert.Equal(t, 3, m.calls, "запрос не должен доходить до транспорта в состоянии open")
+
+	*now = now.Add(time.Second)
+	require.NoError(t, doRequest(t, tr))
+	assert.Equal(t, StateClosed, tr.State("example.com"))
+	assert.Equal(t, float64(StateClosed), sink.GaugeValue(t, "http_test_circuit_breaker_state", map[string]string{"key": "example.com"}))
+	assert.Equal(t, 4, m.calls)
+}
+
+func TestHalfOpenFailure(t *testing.T) {
+	testInit(t)
+	now := testClock(t)
+	m := &MockTransport{statuses: []int{502}}
+	tr := NewTransport(m, "test",
+		WithConsecutiveFailures(1),
+		WithOpenTimeout(time.Second),
+	)
+
+	require.NoError(t, doRequest(t, tr))
+	assert.Equal(t, StateOpen, tr.State("example.com"))
+
+	*now = now.Add(time.Second)
+	require.NoError(t, doRequest(t, tr))
+	assert.Equal(t, StateOpen, tr.State("example.com"))
+	assert.True(t, errors.Is(doRequest(t, tr), ErrOpenState))
+}
+
+func TestFailureRatio(t *testing.T) {
+	testInit(t)
+	testClock(t)
+	m := &MockTransport{statuses: []int{200, 500, 200, 500}}
+	tr := NewTransport(m, "test",
+		WithConsecutiveFailures(0),
+		WithFailureRatio(0.5, 4, time.Minute),
+	)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, doRequest(t, tr))
+		assert.Equal(t, StateClosed, tr.State("example.com"))
+	}
+	require.NoError(t, doRequest(t, tr))
+	assert.Equal(t, StateOpen, tr.State("example.com"))
+}
+
+func TestWindowExpiration(t *testing.T) {
+	now := time.Date(1999, 12, 31, 23, 59, 57, 0, time.UTC)
+	w := newWindow(10 * time.Second)
+	w.add(now, true)
+	w.add(now.Add(5*time.Second), false)
+	assert.Equal(t, counts{requests: 2, failures: 1}, w.totals(now.Add(5*time.Second)))
+	assert.Equal(t, counts{requests: 1, failures: 0}, w.totals(now.Add(12*time.Second)))
+	assert.Equal(t, counts{}, w.totals(now.Add(time.Minute)))
+}
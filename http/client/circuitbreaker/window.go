@@ -0,0 +1,55 @@
+package circuitbreaker
+
+import "time"
+
+const windowBuckets = 10
+
+type counts struct {
+	requests int
+	failures int
+}
+
+// window скользящее окно, разбитое на windowBuckets корзин
+type window struct {
+	size    time.Duration
+	width   time.Duration
+	buckets [windowBuckets]counts
+	starts  [windowBuckets]time.Time
+}
+
+func newWindow(size time.Duration) window {
+	width := size / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return window{size: size, width: width}
+}
+
+func (w *window) add(now time.Time, failure bool) {
+	start := now.Truncate(w.width)
+	i := int(start.UnixNano()/int64(w.width)) % windowBuckets
+	if !w.starts[i].Equal(start) {
+		w.starts[i] = start
+		w.buckets[i] = counts{}
+	}
+	w.buckets[i].requests++
+	if failure {
+		w.buckets[i].failures++
+	}
+}
+
+func (w *window) totals(now time.Time) counts {
+	var c counts
+	for i, start := range w.starts {
+		if !start.IsZero() && now.Sub(start) < w.size {
+			c.requests += w.buckets[i].requests
+			c.failures += w.buckets[i].failures
+		}
+	}
+	return c
+}
+
+func (w *window) reset() {
+	w.buckets = [windowBuckets]counts{}
+	w.starts = [windowBuckets]time.Time{}
+}
@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/MikL9/observability/http/client/retry"
+	"github.com/MikL9/observability/observabilitytest"
 )
 
 func TestNewClientWithRetry200(t *testing.T) {
@@ -76,3 +78,42 @@ func TestNewClientWithRetry500(t *testing.T) {
 	assert.Equal(t, str.String(), "internal server error")
 	assert.Equal(t, 4, attempts)
 }
+
+func TestWithRetryMetrics(t *testing.T) {
+	sink := observabilitytest.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+	cl := NewClient(http.DefaultTransport, "metrics", WithRetry(
+		retry.WithRetryWaitMin(time.Microsecond),
+		retry.WithRetryWaitMax(time.Microsecond),
+		retry.WithRetryMax(2),
+	))
+
+	resp, err := cl.Get(ts.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	assert.Equal(t, 3.0, sink.CounterValue(t, "http_metrics_retry_attempts_total", nil))
+	assert.Equal(t, 2.0, sink.CounterValue(t, "http_metrics_retry_retries_total", nil))
+}
+
+func TestWithHedgingMetrics(t *testing.T) {
+	sink := observabilitytest.New(t)
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+	cl := NewClient(http.DefaultTransport, "hedge", WithHedging(retry.WithHedgeDelay(10*time.Millisecond)))
+
+	resp, err := cl.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1.0, sink.CounterValue(t, "http_hedge_hedges_fired_total", nil))
+	assert.Equal(t, 1.0, sink.CounterValue(t, "http_hedge_hedges_won_total", nil))
+}
@@ -0,0 +1,12 @@
+package concurrency
+
+import "time"
+
+type Option func(*Transport)
+
+// WithQueueTimeout ограничивает время ожидания свободного слота. 0 - ждать до отмены контекста запроса.
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(t *Transport) {
+		t.QueueTimeout = timeout
+	}
+}
@@ -0,0 +1,129 @@
+package concurrency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrQueueTimeout возвращается, если за QueueTimeout не освободился слот для запроса
+var ErrQueueTimeout = errors.NewConstError("concurrency limit queue timeout")
+
+type Transport struct {
+	base http.RoundTripper
+	sem  chan struct{}
+
+	QueueTimeout time.Duration
+
+	WaitDuration     metrics.Histogram
+	InFlightRequests metrics.UpDownCounter
+}
+
+func NewTransport(base http.RoundTripper, serviceID string, limit int, opts ...Option) *Transport {
+	if limit < 1 {
+		limit = 1
+	}
+	tr := &Transport{
+		base: base,
+		sem:  make(chan struct{}, limit),
+		WaitDuration: observability.GetMetrics().Histogram(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "concurrency_limit_wait_seconds",
+			Help:      "Время ожидания свободного слота перед запросом",
+		}),
+		InFlightRequests: observability.GetMetrics().UpDownCounter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "concurrency_limit_in_flight",
+			Help:      "Количество выполняющихся запросов",
+		}),
+	}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	timeStart := time.Now()
+
+	queued, err := t.acquire(ctx)
+	wait := time.Since(timeStart)
+	t.WaitDuration.Observe(ctx, wait.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		trace.SpanFromContext(ctx).AddEvent("concurrency_limit.wait", trace.WithAttributes(
+			attribute.String("concurrency_limit.wait", wait.String()),
+		))
+	}
+
+	t.InFlightRequests.Add(ctx, 1)
+	release := sync.OnceFunc(func() {
+		t.InFlightRequests.Add(ctx, -1)
+		<-t.sem
+	})
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	// слот освобождается только после того, как тело ответа прочитано до конца или закрыто
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// acquire занимает слот, queued - пришлось ли ждать в очереди
+func (t *Transport) acquire(ctx context.Context) (queued bool, err error) {
+	select {
+	case t.sem <- struct{}{}:
+		return false, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if t.QueueTimeout > 0 {
+		timer := time.NewTimer(t.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case t.sem <- struct{}{}:
+		return true, nil
+	case <-timeout:
+		return true, errors.Wrap(ctx, ErrQueueTimeout)
+	case <-ctx.Done():
+		return true, errors.Wrap(ctx, ctx.Err())
+	}
+}
@@ -0,0 +1,45 @@
+package concurrency
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikL9/observability/observabilitytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTransport struct{}
+
+func (t *MockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	sink := observabilitytest.New(t)
+	tr := NewTransport(&MockTransport{}, "test", 1, WithQueueTimeout(10*time.Millisecond))
+
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), sink.GaugeValue(t, "http_test_concurrency_limit_in_flight", nil))
+
+	// слот занят, пока не прочитано тело первого ответа
+	_, err = tr.RoundTrip(req)
+	assert.True(t, errors.Is(err, ErrQueueTimeout))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, float64(0), sink.GaugeValue(t, "http_test_concurrency_limit_in_flight", nil))
+
+	resp, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
@@ -0,0 +1,362 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/MikL9/observability/http/client/circuitbreaker"
+	"github.com/MikL9/observability/http/client/logger"
+	"github.com/MikL9/observability/http/client/retry"
+)
+
+// Config декларативное описание клиента. Может быть загружен из YAML (yaml.v3)
+// или из переменных окружения через LoadConfigFromEnv.
+//
+// NewClientFromConfig всегда собирает транспорты в одном порядке, от внешнего к внутреннему:
+//
+//	tracing -> log -> metrics -> circuit breaker -> retry -> hedging -> rate limit -> concurrency limit -> base
+//
+// Tracing снаружи, чтобы логи и спаны попыток попадали в трейс запроса.
+// Log и metrics описывают логический вызов целиком, вместе с повторами.
+// Circuit breaker снаружи retry: открытый breaker сразу прерывает вызов, а не повторяется с backoff.
+// Rate limit и concurrency limit внутри retry, так как каждая попытка расходует квоту партнера.
+type Config struct {
+	// Timeout ограничивает весь вызов вместе с повторами
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT"`
+
+	Log     LogConfig     `yaml:"log" env:"LOG"`
+	Metrics MetricsConfig `yaml:"metrics" env:"METRICS"`
+	Tracing TracingConfig `yaml:"tracing" env:"TRACING"`
+
+	Retry            *RetryConfig            `yaml:"retry" env:"RETRY"`
+	CircuitBreaker   *CircuitBreakerConfig   `yaml:"circuit_breaker" env:"CIRCUIT_BREAKER"`
+	RateLimit        *RateLimitConfig        `yaml:"rate_limit" env:"RATE_LIMIT"`
+	ConcurrencyLimit *ConcurrencyLimitConfig `yaml:"concurrency_limit" env:"CONCURRENCY_LIMIT"`
+	Hedging          *HedgingConfig          `yaml:"hedging" env:"HEDGING"`
+
+	Transport TransportConfig `yaml:"transport" env:"TRANSPORT"`
+	TLS       *TLSConfig      `yaml:"tls" env:"TLS"`
+}
+
+type LogConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+	// Body режим логирования тел: always (по умолчанию), on_error или never
+	Body string `yaml:"body" env:"BODY"`
+	// SampleRate доля логируемых успешных вызовов, 0 - логировать все
+	SampleRate      float64 `yaml:"sample_rate" env:"SAMPLE_RATE"`
+	MaxBodySize     int     `yaml:"max_body_size" env:"MAX_BODY_SIZE"`
+	SeparateRecords bool    `yaml:"separate_records" env:"SEPARATE_RECORDS"`
+}
+
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+}
+
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED"`
+}
+
+type RetryConfig struct {
+	// Max количество повторов, 0 - значение по умолчанию retry.Transport
+	Max            int           `yaml:"max" env:"MAX"`
+	WaitMin        time.Duration `yaml:"wait_min" env:"WAIT_MIN"`
+	WaitMax        time.Duration `yaml:"wait_max" env:"WAIT_MAX"`
+	AttemptTimeout time.Duration `yaml:"attempt_timeout" env:"ATTEMPT_TIMEOUT"`
+	// BudgetRatio доля повторов от количества запросов за BudgetWindow, 0 - без бюджета
+	BudgetRatio        float64       `yaml:"budget_ratio" env:"BUDGET_RATIO"`
+	BudgetMinRetries   int           `yaml:"budget_min_retries" env:"BUDGET_MIN_RETRIES"`
+	BudgetWindow       time.Duration `yaml:"budget_window" env:"BUDGET_WINDOW"`
+	RetryNonIdempotent bool          `yaml:"retry_non_idempotent" env:"RETRY_NON_IDEMPOTENT"`
+	LogAttempts        bool          `yaml:"log_attempts" env:"LOG_ATTEMPTS"`
+}
+
+type CircuitBreakerConfig struct {
+	ConsecutiveFailures int           `yaml:"consecutive_failures" env:"CONSECUTIVE_FAILURES"`
+	FailureRatio        float64       `yaml:"failure_ratio" env:"FAILURE_RATIO"`
+	MinRequests         int           `yaml:"min_requests" env:"MIN_REQUESTS"`
+	Window              time.Duration `yaml:"window" env:"WINDOW"`
+	OpenTimeout         time.Duration `yaml:"open_timeout" env:"OPEN_TIMEOUT"`
+	HalfOpenMaxRequests int           `yaml:"half_open_max_requests" env:"HALF_OPEN_MAX_REQUESTS"`
+}
+
+type RateLimitConfig struct {
+	Rate  float64 `yaml:"rate" env:"RATE"`
+	Burst int     `yaml:"burst" env:"BURST"`
+}
+
+type ConcurrencyLimitConfig struct {
+	Limit        int           `yaml:"limit" env:"LIMIT"`
+	QueueTimeout time.Duration `yaml:"queue_timeout" env:"QUEUE_TIMEOUT"`
+}
+
+type HedgingConfig struct {
+	Delay      time.Duration `yaml:"delay" env:"DELAY"`
+	Percentile float64       `yaml:"percentile" env:"PERCENTILE"`
+	MaxHedges  int           `yaml:"max_hedges" env:"MAX_HEDGES"`
+}
+
+type TransportConfig struct {
+	TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout" env:"TLS_HANDSHAKE_TIMEOUT"`
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout" env:"RESPONSE_HEADER_TIMEOUT"`
+	IdleConnTimeout       time.Duration `yaml:"idle_conn_timeout" env:"IDLE_CONN_TIMEOUT"`
+	MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host" env:"MAX_IDLE_CONNS_PER_HOST"`
+}
+
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file" env:"CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KEY_FILE"`
+	ServerName         string `yaml:"server_name" env:"SERVER_NAME"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"INSECURE_SKIP_VERIFY"`
+}
+
+// Validate проверяет значения и несовместимые комбинации настроек
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must not be negative"))
+	}
+	if _, ok := logBodyModes[c.Log.Body]; !ok {
+		errs = append(errs, fmt.Errorf("log.body must be one of always, on_error, never, got %q", c.Log.Body))
+	}
+	if c.Log.SampleRate < 0 || c.Log.SampleRate > 1 {
+		errs = append(errs, errors.New("log.sample_rate must be in [0, 1]"))
+	}
+	if r := c.Retry; r != nil {
+		if r.Max < 0 {
+			errs = append(errs, errors.New("retry.max must not be negative"))
+		}
+		if r.WaitMax > 0 && r.WaitMin > r.WaitMax {
+			errs = append(errs, errors.New("retry.wait_min must not exceed retry.wait_max"))
+		}
+		if r.BudgetRatio < 0 {
+			errs = append(errs, errors.New("retry.budget_ratio must not be negative"))
+		}
+		if r.BudgetRatio > 0 && r.BudgetWindow <= 0 {
+			errs = append(errs, errors.New("retry.budget_window is required with retry.budget_ratio"))
+		}
+		if c.Timeout > 0 && r.AttemptTimeout >= c.Timeout {
+			errs = append(errs, errors.New("retry.attempt_timeout must be less than timeout, otherwise no retry fits"))
+		}
+	}
+	if cb := c.CircuitBreaker; cb != nil {
+		if cb.ConsecutiveFailures <= 0 && cb.FailureRatio <= 0 {
+			errs = append(errs, errors.New("circuit_breaker requires consecutive_failures or failure_ratio"))
+		}
+		if cb.FailureRatio > 1 {
+			errs = append(errs, errors.New("circuit_breaker.failure_ratio must not exceed 1"))
+		}
+		if cb.FailureRatio > 0 && cb.Window <= 0 {
+			errs = append(errs, errors.New("circuit_breaker.window is required with circuit_breaker.failure_ratio"))
+		}
+	}
+	if rl := c.RateLimit; rl != nil && rl.Rate <= 0 {
+		errs = append(errs, errors.New("rate_limit.rate must be positive"))
+	}
+	if cl := c.ConcurrencyLimit; cl != nil && cl.Limit <= 0 {
+		errs = append(errs, errors.New("concurrency_limit.limit must be positive"))
+	}
+	if h := c.Hedging; h != nil {
+		if h.Percentile < 0 || h.Percentile >= 1 {
+			errs = append(errs, errors.New("hedging.percentile must be in [0, 1)"))
+		}
+		if h.Delay <= 0 && h.Percentile == 0 {
+			errs = append(errs, errors.New("hedging requires delay or percentile"))
+		}
+		if c.Timeout > 0 && h.Delay >= c.Timeout {
+			errs = append(errs, errors.New("hedging.delay must be less than timeout, otherwise hedges are never fired"))
+		}
+		if c.ConcurrencyLimit != nil && max(h.MaxHedges, 1) >= c.ConcurrencyLimit.Limit {
+			errs = append(errs, errors.New("hedging.max_hedges must be less than concurrency_limit.limit"))
+		}
+	}
+	if t := c.TLS; t != nil {
+		if (t.CertFile == "") != (t.KeyFile == "") {
+			errs = append(errs, errors.New("tls.cert_file and tls.key_file must be set together"))
+		}
+		if t.InsecureSkipVerify && t.CAFile != "" {
+			errs = append(errs, errors.New("tls.ca_file is ignored with tls.insecure_skip_verify"))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("client config: %w", err)
+	}
+	return nil
+}
+
+// NewClientFromConfig создает клиент по конфигурации, порядок транспортов описан в Config
+func NewClientFromConfig(serviceID string, cfg Config) (*http.Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	base, err := cfg.baseTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	// NewClient оборачивает транспорт в порядке слайса, поэтому первый элемент самый внутренний
+	var opts []Option
+	if cl := cfg.ConcurrencyLimit; cl != nil {
+		opts = append(opts, WithConcurrencyLimit(cl.Limit, cl.QueueTimeout))
+	}
+	if rl := cfg.RateLimit; rl != nil {
+		opts = append(opts, WithRateLimit(rl.Rate, rl.Burst))
+	}
+	if h := cfg.Hedging; h != nil {
+		opts = append(opts, WithHedging(cfg.hedgingOptions(serviceID)...))
+	}
+	if cfg.Retry != nil {
+		opts = append(opts, WithRetry(cfg.retryOptions(serviceID)...))
+	}
+	if cb := cfg.CircuitBreaker; cb != nil {
+		opts = append(opts, WithCircuitBreaker(cfg.circuitBreakerOptions()...))
+	}
+	if cfg.Metrics.Enabled {
+		opts = append(opts, WithMetrics())
+	}
+	if cfg.Log.Enabled {
+		opts = append(opts, WithLog(cfg.logOptions()...))
+	}
+	if cfg.Tracing.Enabled {
+		opts = append(opts, WithTracing())
+	}
+
+	cl := NewClient(base, serviceID, opts...)
+	cl.Timeout = cfg.Timeout
+	return cl, nil
+}
+
+func (c *Config) baseTransport() (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if c.Transport.TLSHandshakeTimeout > 0 {
+		tr.TLSHandshakeTimeout = c.Transport.TLSHandshakeTimeout
+	}
+	if c.Transport.ResponseHeaderTimeout > 0 {
+		tr.ResponseHeaderTimeout = c.Transport.ResponseHeaderTimeout
+	}
+	if c.Transport.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = c.Transport.IdleConnTimeout
+	}
+	if c.Transport.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = c.Transport.MaxIdleConnsPerHost
+	}
+	if c.TLS == nil {
+		return tr, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.TLS.ServerName,
+		InsecureSkipVerify: c.TLS.InsecureSkipVerify, //nolint:gosec // явно включается в конфигурации
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.TLS.CAFile != "" {
+		ca, err := os.ReadFile(c.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client config: read tls.ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("client config: tls.ca_file %s contains no certificates", c.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client config: load tls certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	tr.TLSClientConfig = tlsConfig
+	return tr, nil
+}
+
+var logBodyModes = map[string]logger.BodyMode{
+	"":         logger.BodyAlways,
+	"always":   logger.BodyAlways,
+	"on_error": logger.BodyOnError,
+	"never":    logger.BodyNever,
+}
+
+func (c *Config) logOptions() []logger.Option {
+	policy := []logger.PolicyOption{logger.WithBodyMode(logBodyModes[c.Log.Body])}
+	if c.Log.SampleRate > 0 {
+		policy = append(policy, logger.WithSampleRate(c.Log.SampleRate))
+	}
+	if c.Log.MaxBodySize > 0 {
+		policy = append(policy, logger.WithMaxBodySize(c.Log.MaxBodySize))
+	}
+	opts := []logger.Option{logger.WithPolicy(policy...)}
+	if c.Log.SeparateRecords {
+		opts = append(opts, logger.WithSeparateRecords())
+	}
+	return opts
+}
+
+func (c *Config) retryOptions(serviceID string) []retry.Option {
+	r := c.Retry
+	var opts []retry.Option
+	if r.Max > 0 {
+		opts = append(opts, retry.WithRetryMax(r.Max))
+	}
+	if r.WaitMin > 0 {
+		opts = append(opts, retry.WithRetryWaitMin(r.WaitMin))
+	}
+	if r.WaitMax > 0 {
+		opts = append(opts, retry.WithRetryWaitMax(r.WaitMax))
+	}
+	if r.AttemptTimeout > 0 {
+		opts = append(opts, retry.WithAttemptTimeout(r.AttemptTimeout))
+	}
+	if r.BudgetRatio > 0 {
+		opts = append(opts, retry.WithBudget(retry.NewBudget(r.BudgetRatio, r.BudgetMinRetries, r.BudgetWindow)))
+	}
+	if r.RetryNonIdempotent {
+		opts = append(opts, retry.WithRetryNonIdempotent())
+	}
+	if r.LogAttempts {
+		opts = append(opts, retry.WithAttemptLog())
+	}
+	if c.Metrics.Enabled {
+		opts = append(opts, retry.WithMetrics(serviceID))
+	}
+	return opts
+}
+
+func (c *Config) hedgingOptions(serviceID string) []retry.HedgingOption {
+	h := c.Hedging
+	var opts []retry.HedgingOption
+	if h.Delay > 0 {
+		opts = append(opts, retry.WithHedgeDelay(h.Delay))
+	}
+	if h.Percentile > 0 {
+		opts = append(opts, retry.WithHedgePercentile(h.Percentile))
+	}
+	if h.MaxHedges > 0 {
+		opts = append(opts, retry.WithMaxHedges(h.MaxHedges))
+	}
+	if c.Metrics.Enabled {
+		opts = append(opts, retry.WithHedgingMetrics(serviceID))
+	}
+	return opts
+}
+
+func (c *Config) circuitBreakerOptions() []circuitbreaker.Option {
+	cb := c.CircuitBreaker
+	opts := []circuitbreaker.Option{circuitbreaker.WithConsecutiveFailures(cb.ConsecutiveFailures)}
+	if cb.FailureRatio > 0 {
+		opts = append(opts, circuitbreaker.WithFailureRatio(cb.FailureRatio, cb.MinRequests, cb.Window))
+	}
+	if cb.OpenTimeout > 0 {
+		opts = append(opts, circuitbreaker.WithOpenTimeout(cb.OpenTimeout))
+	}
+	if cb.HalfOpenMaxRequests > 0 {
+		opts = append(opts, circuitbreaker.WithHalfOpenMaxRequests(cb.HalfOpenMaxRequests))
+	}
+	return opts
+}
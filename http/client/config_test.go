@@ -0,0 +1,108 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("PARTNER_TIMEOUT", "3s")
+	t.Setenv("PARTNER_LOG_ENABLED", "true")
+	t.Setenv("PARTNER_RETRY_MAX", "2")
+	t.Setenv("PARTNER_RETRY_BUDGET_RATIO", "0.1")
+	t.Setenv("PARTNER_RATE_LIMIT_RATE", "50")
+
+	cfg, err := LoadConfigFromEnv("PARTNER")
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+	assert.True(t, cfg.Log.Enabled)
+	require.NotNil(t, cfg.Retry)
+	assert.Equal(t, 2, cfg.Retry.Max)
+	assert.Equal(t, 0.1, cfg.Retry.BudgetRatio)
+	require.NotNil(t, cfg.RateLimit)
+	assert.Equal(t, float64(50), cfg.RateLimit.Rate)
+	assert.Nil(t, cfg.CircuitBreaker, "блок без переменных окружения не должен создаваться")
+
+	t.Setenv("PARTNER_RETRY_MAX", "two")
+	_, err = LoadConfigFromEnv("PARTNER")
+	assert.ErrorContains(t, err, "PARTNER_RETRY_MAX")
+}
+
+func TestConfigFromYAML(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+timeout: 5s
+retry:
+  max: 3
+  attempt_timeout: 1s
+circuit_breaker:
+  consecutive_failures: 5
+  open_timeout: 30s
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, time.Second, cfg.Retry.AttemptTimeout)
+	assert.Equal(t, 30*time.Second, cfg.CircuitBreaker.OpenTimeout)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{
+		Timeout:          time.Second,
+		Retry:            &RetryConfig{AttemptTimeout: time.Second},
+		Hedging:          &HedgingConfig{Delay: 10 * time.Millisecond, MaxHedges: 2},
+		ConcurrencyLimit: &ConcurrencyLimitConfig{Limit: 2},
+		TLS:              &TLSConfig{CertFile: "cert.pem"},
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "retry.attempt_timeout")
+	assert.ErrorContains(t, err, "hedging.max_hedges")
+	assert.ErrorContains(t, err, "tls.cert_file")
+
+	_, err = NewClientFromConfig("test", cfg)
+	require.Error(t, err)
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	require.NoError(t, observability.Init("test", observability.WithPrometheus(prometheus.NewRegistry())))
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	cl, err := NewClientFromConfig("config_test", Config{
+		Timeout: time.Second,
+		Log:     LogConfig{Enabled: true},
+		Metrics: MetricsConfig{Enabled: true},
+		Tracing: TracingConfig{Enabled: true},
+		Retry: &RetryConfig{
+			Max:     2,
+			WaitMin: time.Microsecond,
+			WaitMax: time.Microsecond,
+		},
+		CircuitBreaker: &CircuitBreakerConfig{ConsecutiveFailures: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, cl.Timeout)
+
+	resp, err := cl.Get(ts.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, 3, attempts, "breaker снаружи retry считает логический вызов, а не попытки")
+
+	_, err = cl.Get(ts.URL)
+	assert.ErrorContains(t, err, "circuit breaker is open")
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadConfigFromEnv заполняет Config из переменных окружения. Имя переменной
+// складывается из prefix и тегов env вложенных полей через "_", например
+// PARTNER_RETRY_MAX для prefix "PARTNER". Блоки-указатели (retry, circuit_breaker и т.д.)
+// создаются, только если задана хотя бы одна их переменная.
+func LoadConfigFromEnv(prefix string) (Config, error) {
+	var cfg Config
+	if _, err := loadEnv(reflect.ValueOf(&cfg).Elem(), strings.TrimSuffix(prefix, "_")); err != nil {
+		return Config{}, fmt.Errorf("client config: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadEnv возвращает true, если была найдена хотя бы одна переменная
+func loadEnv(v reflect.Value, prefix string) (bool, error) {
+	var found bool
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		name := tag
+		if prefix != "" {
+			name = prefix + "_" + tag
+		}
+
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			ok, err := loadEnv(fv, name)
+			if err != nil {
+				return false, err
+			}
+			found = found || ok
+		case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+			nested := reflect.New(fv.Type().Elem())
+			ok, err := loadEnv(nested.Elem(), name)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				fv.Set(nested)
+				found = true
+			}
+		default:
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setEnvValue(fv, raw); err != nil {
+				return false, fmt.Errorf("%s: %w", name, err)
+			}
+			found = true
+		}
+	}
+	return found, nil
+}
+
+func setEnvValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package logger
+
+import "strings"
+
+type BodyMode int
+
+const (
+	// BodyAlways логирует тела запроса и ответа для каждого вызова
+	BodyAlways BodyMode = iota
+	// BodyOnError логирует тела только при ошибке или статусе ответа не 2xx
+	BodyOnError
+	// BodyNever не логирует тела
+	BodyNever
+)
+
+// Policy определяет, что и как логируется для вызова
+type Policy struct {
+	Body BodyMode
+	// SampleRate доля успешных вызовов, которые попадают в лог. Ошибки логируются всегда
+	SampleRate float64
+	// MaxBodySize ограничивает размер логируемого тела в байтах, из тел запроса и ответа читается только это начало
+	MaxBodySize int
+}
+
+type PolicyOption func(*Policy)
+
+func WithBodyMode(mode BodyMode) PolicyOption {
+	return func(p *Policy) {
+		p.Body = mode
+	}
+}
+
+func WithSampleRate(rate float64) PolicyOption {
+	return func(p *Policy) {
+		p.SampleRate = rate
+	}
+}
+
+func WithMaxBodySize(size int) PolicyOption {
+	return func(p *Policy) {
+		p.MaxBodySize = size
+	}
+}
+
+type override struct {
+	host       string
+	pathPrefix string
+	policy     Policy
+}
+
+func (o *override) match(host, path string) bool {
+	return (o.host == "" || o.host == host) && strings.HasPrefix(path, o.pathPrefix)
+}
+
+type Option func(*Transport)
+
+// WithPolicy меняет политику по умолчанию
+func WithPolicy(opts ...PolicyOption) Option {
+	return func(t *Transport) {
+		for _, opt := range opts {
+			opt(&t.policy)
+		}
+	}
+}
+
+// WithOverride задает политику для хоста и префикса пути. Пустой host подходит для любого хоста.
+// Политика строится от политики по умолчанию, действующей на момент применения опции.
+// Побеждает первое подходящее переопределение.
+func WithOverride(host, pathPrefix string, opts ...PolicyOption) Option {
+	return func(t *Transport) {
+		o := override{host: host, pathPrefix: pathPrefix, policy: t.policy}
+		for _, opt := range opts {
+			opt(&o.policy)
+		}
+		t.overrides = append(t.overrides, o)
+	}
+}
+
+// WithSeparateRecords логирует запрос до его выполнения и ответ отдельной записью,
+// чтобы долгие вызовы были видны в логе сразу.
+// В режиме BodyOnError тело запроса попадает только в запись об ответе.
+func WithSeparateRecords() Option {
+	return func(t *Transport) {
+		t.separateRecords = true
+	}
+}
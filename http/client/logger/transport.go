@@ -2,6 +2,7 @@ package logger
 
 import (
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
@@ -10,31 +11,103 @@ import (
 	"github.com/MikL9/observability/utils"
 )
 
+// randFloat возвращает случайное число для сэмплирования, подменяется в тестах
+var randFloat = rand.Float64
+
 type Transport struct {
 	rt        http.RoundTripper
 	serviceID string
+
+	policy          Policy
+	overrides       []override
+	separateRecords bool
 }
 
-func NewTransport(rt http.RoundTripper, serviceID string) *Transport {
-	return &Transport{rt: rt, serviceID: serviceID}
+func NewTransport(rt http.RoundTripper, serviceID string, opts ...Option) *Transport {
+	t := &Transport{
+		rt:        rt,
+		serviceID: serviceID,
+		policy: Policy{
+			Body:        BodyAlways,
+			SampleRate:  1,
+			MaxBodySize: 10 * utils.Kilobyte,
+		},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Transport) policyFor(r *http.Request) Policy {
+	for i := range t.overrides {
+		if t.overrides[i].match(r.URL.Host, r.URL.Path) {
+			return t.overrides[i].policy
+		}
+	}
+	return t.policy
 }
 
 func (t *Transport) RoundTrip(r *http.Request) (resp *http.Response, err error) {
 	var (
 		timeStart = time.Now()
 		ctx       = r.Context()
-		attrs     = []slog.Attr{utils.KeyRequest(r, true)}
+		policy    = t.policyFor(r)
+		reqBody   []byte
+		sampled   = policy.SampleRate >= 1 || randFloat() < policy.SampleRate
 	)
 
+	if policy.Body != BodyNever && utils.HasRequestBody(r) {
+		reqBody = utils.GetRequestBodyPrefix(r, policy.MaxBodySize)
+	}
+	if t.separateRecords && sampled {
+		body := reqBody
+		if policy.Body != BodyAlways {
+			body = nil
+		}
+		logger.Info(ctx, t.serviceID+" query started", utils.KeyRequestWithBody(r, body, policy.MaxBodySize, true))
+	}
+
 	resp, err = t.rt.RoundTrip(r)
 
+	failed := err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300
+	// ошибки логируются всегда, успешные вызовы - согласно SampleRate
+	if !failed && !sampled {
+		return resp, err
+	}
+	if policy.Body == BodyOnError && !failed {
+		reqBody = nil
+	}
+
+	var attrs []slog.Attr
+	if !t.separateRecords || !sampled || (policy.Body == BodyOnError && failed) {
+		attrs = append(attrs, utils.KeyRequestWithBody(r, reqBody, policy.MaxBodySize, true))
+	}
 	if err != nil {
 		attrs = append(attrs, utils.KeyError(err), utils.KeyDuration(timeStart))
 		logger.Error(ctx, errors.New(ctx, t.serviceID+" query"), attrs...)
+		return resp, err
+	}
+
+	respData := &utils.ResponseData{Status: resp.StatusCode}
+	bodyLimit := policy.MaxBodySize
+	if policy.Body == BodyNever || (policy.Body == BodyOnError && !failed) {
+		bodyLimit = 0
 	} else {
-		attrs = append(attrs,
-			utils.KeyDuration(timeStart),
-			utils.KeyResponse(&utils.ResponseData{Status: resp.StatusCode, Body: utils.GetResponseBodyCopy(resp)}, true))
+		respData.Body = utils.GetResponseBodyPrefix(resp, bodyLimit)
+		if resp.ContentLength > 0 {
+			respData.Length = int(resp.ContentLength)
+		}
+	}
+	attrs = append(attrs,
+		utils.KeyDuration(timeStart),
+		utils.KeyResponseWithLimit(respData, bodyLimit, true))
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		logger.Error(ctx, errors.New(ctx, t.serviceID+" query"), attrs...)
+	case failed:
+		logger.Warn(ctx, t.serviceID+" query", attrs...)
+	default:
 		logger.Info(ctx, t.serviceID+" query", attrs...)
 	}
 	return resp, err
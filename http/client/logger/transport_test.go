@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MikL9/observability/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTransport struct {
+	status int
+}
+
+func (t *MockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: t.status, Body: io.NopCloser(strings.NewReader(`{"result":"ok"}`))}, nil
+}
+
+func setupLogger(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	require.NoError(t, logger.SetupLogger(slog.NewJSONHandler(buf, nil)))
+	return buf
+}
+
+func readRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func doRequest(t *testing.T, tr *Transport, path string) {
+	req, err := http.NewRequest("POST", "http://example.com"+path, strings.NewReader(`{"id":1}`))
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"result":"ok"}`, string(body), "тело ответа должно оставаться доступным")
+}
+
+func TestBodyOnError(t *testing.T) {
+	buf := setupLogger(t)
+	m := &MockTransport{status: 200}
+	tr := NewTransport(m, "test", WithPolicy(WithBodyMode(BodyOnError)))
+
+	doRequest(t, tr, "/path")
+	m.status = 500
+	doRequest(t, tr, "/path")
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 2)
+	assert.NotContains(t, records[0]["request"], "body_text")
+	assert.NotContains(t, records[0]["response"], "body_text")
+	assert.Equal(t, `{"id":1}`, records[1]["request"].(map[string]any)["body_text"])
+	assert.Equal(t, `{"result":"ok"}`, records[1]["response"].(map[string]any)["body_text"])
+}
+
+func TestSampleRateAndOverride(t *testing.T) {
+	buf := setupLogger(t)
+	randFloat = func() float64 { return 0.5 }
+	t.Cleanup(func() {
+		randFloat = rand.Float64
+	})
+
+	m := &MockTransport{status: 200}
+	tr := NewTransport(m, "test",
+		WithPolicy(WithSampleRate(0.1)),
+		WithOverride("example.com", "/important", WithSampleRate(1), WithMaxBodySize(4)),
+	)
+
+	doRequest(t, tr, "/path")
+	doRequest(t, tr, "/important/path")
+	m.status = 502
+	doRequest(t, tr, "/path")
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 2, "успешный вызов вне выборки не логируется")
+	body, _ := records[0]["response"].(map[string]any)["body_text"].(string)
+	assert.LessOrEqual(t, len(body), 4, "тело обрезается до MaxBodySize")
+	assert.Equal(t, float64(502), records[1]["response"].(map[string]any)["status"])
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "ERROR", records[1]["level"])
+}
+
+func TestClientErrorLevel(t *testing.T) {
+	buf := setupLogger(t)
+	tr := NewTransport(&MockTransport{status: 404}, "test")
+
+	doRequest(t, tr, "/path")
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+}
+
+// countingReader считает байты, прочитанные из тела ответа
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+type bodyTransport struct {
+	body *countingReader
+}
+
+func (t *bodyTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, ContentLength: 1024, Body: io.NopCloser(t.body)}, nil
+}
+
+func TestMaxBodySizeLimitsRead(t *testing.T) {
+	buf := setupLogger(t)
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("a", 1024))}
+	tr := NewTransport(&bodyTransport{body: body}, "test", WithPolicy(WithMaxBodySize(16)))
+
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 16, body.read, "логируется только начало тела")
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, data, 1024, "тело ответа должно оставаться доступным целиком")
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 1)
+	response := records[0]["response"].(map[string]any)
+	assert.Equal(t, strings.Repeat("a", 16), response["body_text"])
+	assert.Equal(t, float64(1024), response["content_length"])
+}
+
+func TestSeparateRecords(t *testing.T) {
+	buf := setupLogger(t)
+	tr := NewTransport(&MockTransport{status: 200}, "test", WithSeparateRecords())
+
+	doRequest(t, tr, "/path")
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "test query started", records[0]["msg"])
+	assert.Contains(t, records[0], "request")
+	assert.Equal(t, "test query", records[1]["msg"])
+	assert.NotContains(t, records[1], "request")
+	assert.Contains(t, records[1], "response")
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	buf := setupLogger(t)
+	payload := strings.Repeat("a", 100)
+	var sent string
+	tr := NewTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(r.Body)
+		sent = string(body)
+		return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader("")), ContentLength: 0}, err
+	}), "test", WithPolicy(WithMaxBodySize(8)))
+
+	req, err := http.NewRequest("POST", "http://example.com/path", strings.NewReader(payload))
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, payload, sent, "тело запроса должно передаваться целиком")
+	records := readRecords(t, buf)
+	require.Len(t, records, 1)
+	body, _ := records[0]["request"].(map[string]any)["body_text"].(string)
+	assert.NotEmpty(t, body)
+	assert.LessOrEqual(t, len(body), 8, "из тела запроса читается только начало")
+}
@@ -5,43 +5,45 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/metrics"
 )
 
 type Transport struct {
 	rt http.RoundTripper
 
-	RequestsCounter         *prometheus.CounterVec
-	RequestsErrorCounter    *prometheus.CounterVec
-	RequestsDuration        *prometheus.HistogramVec
-	ActiveConnectionCounter prometheus.Gauge
+	RequestsCounter         metrics.Counter
+	RequestsErrorCounter    metrics.Counter
+	RequestsDuration        metrics.Histogram
+	ActiveConnectionCounter metrics.UpDownCounter
 }
 
 func NewTransport(rt http.RoundTripper, serviceID string) *Transport {
+	provider := observability.GetMetrics()
 	return &Transport{
 		rt: rt,
-		RequestsCounter: promauto.With(observability.GetRegisterer()).NewCounterVec(prometheus.CounterOpts{
+		RequestsCounter: provider.Counter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_total",
 			Help:      "Общее количество запросов",
-		}, []string{"path", "status"}),
-		RequestsErrorCounter: promauto.With(observability.GetRegisterer()).NewCounterVec(prometheus.CounterOpts{
+			Labels:    []string{"path", "status"},
+		}),
+		RequestsErrorCounter: provider.Counter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_total_error",
 			Help:      "Общее количество ошибочных запросов",
-		}, []string{"path"}),
-		RequestsDuration: promauto.With(observability.GetRegisterer()).NewHistogramVec(prometheus.HistogramOpts{
+			Labels:    []string{"path"},
+		}),
+		RequestsDuration: provider.Histogram(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_duration",
 			Help:      "Продолжительность запросов",
-			Buckets:   prometheus.DefBuckets,
-		}, []string{"path"}),
-		ActiveConnectionCounter: promauto.With(observability.GetRegisterer()).NewGauge(prometheus.GaugeOpts{
+			Labels:    []string{"path"},
+		}),
+		ActiveConnectionCounter: provider.UpDownCounter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "active_connection_total",
@@ -52,21 +54,22 @@ func NewTransport(rt http.RoundTripper, serviceID string) *Transport {
 
 func (t *Transport) RoundTrip(r *http.Request) (resp *http.Response, err error) {
 	var (
+		ctx        = r.Context()
 		timeStart  = time.Now()
 		statusCode string
 	)
-	t.ActiveConnectionCounter.Inc()
+	t.ActiveConnectionCounter.Add(ctx, 1)
 
 	resp, err = t.rt.RoundTrip(r)
 
 	if resp != nil {
 		statusCode = strconv.Itoa(resp.StatusCode)
 	}
-	t.RequestsCounter.WithLabelValues(r.RequestURI, statusCode).Inc()
-	t.ActiveConnectionCounter.Dec()
-	t.RequestsDuration.WithLabelValues(r.RequestURI).Observe(time.Since(timeStart).Seconds())
+	t.RequestsCounter.Add(ctx, 1, r.RequestURI, statusCode)
+	t.ActiveConnectionCounter.Add(ctx, -1)
+	t.RequestsDuration.Observe(ctx, time.Since(timeStart).Seconds(), r.RequestURI)
 	if err != nil {
-		t.RequestsErrorCounter.WithLabelValues(r.RequestURI).Inc()
+		t.RequestsErrorCounter.Add(ctx, 1, r.RequestURI)
 	}
 	return resp, err
 }
@@ -2,18 +2,22 @@ package client
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/MikL9/observability/http/client/circuitbreaker"
+	"github.com/MikL9/observability/http/client/concurrency"
 	"github.com/MikL9/observability/http/client/logger"
 	"github.com/MikL9/observability/http/client/metric"
+	"github.com/MikL9/observability/http/client/ratelimit"
 	"github.com/MikL9/observability/http/client/retry"
 	"github.com/MikL9/observability/http/client/tracing"
 )
 
 type Option func(rt http.RoundTripper, serviceID string) http.RoundTripper
 
-func WithLog() Option {
+func WithLog(opts ...logger.Option) Option {
 	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
-		return logger.NewTransport(rt, serviceID)
+		return logger.NewTransport(rt, serviceID, opts...)
 	}
 }
 
@@ -29,8 +33,37 @@ func WithMetrics() Option {
 	}
 }
 
+// WithRetry повторяет неудачные запросы. Счетчики попыток и повторов включены с подсистемой serviceID
 func WithRetry(opts ...retry.Option) Option {
 	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
-		return retry.NewTransport(rt, opts...)
+		return retry.NewTransport(rt, append([]retry.Option{retry.WithMetrics(serviceID)}, opts...)...)
+	}
+}
+
+func WithCircuitBreaker(opts ...circuitbreaker.Option) Option {
+	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
+		return circuitbreaker.NewTransport(rt, serviceID, opts...)
+	}
+}
+
+// WithRateLimit ограничивает количество запросов в секунду (token bucket, по умолчанию на каждый хост)
+func WithRateLimit(rate float64, burst int, opts ...ratelimit.Option) Option {
+	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
+		return ratelimit.NewTransport(rt, serviceID, rate, burst, opts...)
+	}
+}
+
+// WithConcurrencyLimit ограничивает количество одновременно выполняющихся запросов
+func WithConcurrencyLimit(limit int, queueTimeout time.Duration) Option {
+	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
+		return concurrency.NewTransport(rt, serviceID, limit, concurrency.WithQueueTimeout(queueTimeout))
+	}
+}
+
+// WithHedging отправляет дополнительный запрос, если идемпотентный запрос не получил ответ за заданное время.
+// Счетчики отправленных и выигравших запросов включены с подсистемой serviceID
+func WithHedging(opts ...retry.HedgingOption) Option {
+	return func(rt http.RoundTripper, serviceID string) http.RoundTripper {
+		return retry.NewHedgingTransport(rt, append([]retry.HedgingOption{retry.WithHedgingMetrics(serviceID)}, opts...)...)
 	}
 }
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket классический token bucket: rate токенов в секунду, не более burst токенов в запасе
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve забирает токен и возвращает время, которое нужно подождать перед запросом.
+// Токен может уйти в минус - так ожидающие запросы выстраиваются в очередь.
+func (b *bucket) reserve(now time.Time, rate float64, burst int) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed.Seconds()*rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// cancel возвращает токен, если запрос так и не был выполнен
+func (b *bucket) cancel(burst int) {
+	b.tokens = math.Min(float64(burst), b.tokens+1)
+}
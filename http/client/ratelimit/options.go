@@ -0,0 +1,10 @@
+package ratelimit
+
+type Option func(*Transport)
+
+// WithKeyFunc задает ключ, для которого ведется отдельный token bucket. По умолчанию - хост запроса.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(t *Transport) {
+		t.KeyFunc = f
+	}
+}
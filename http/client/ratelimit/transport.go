@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrLimitExceeded возвращается, если дедлайн запроса наступит раньше, чем освободится токен
+var ErrLimitExceeded = errors.NewConstError("rate limit wait exceeds context deadline")
+
+// timeNow sets the function that returns the current time.
+// This defaults to time.Now. Changes to this should only be done in tests.
+var timeNow = time.Now
+
+// KeyFunc возвращает ключ, по которому запросы группируются в отдельный token bucket
+type KeyFunc func(*http.Request) string
+
+// HostKey ведет отдельный token bucket на каждый хост
+func HostKey(r *http.Request) string {
+	return r.URL.Host
+}
+
+func keyRateLimit(v string) slog.Attr { return slog.String("rate_limit_key", v) }
+
+type Transport struct {
+	base http.RoundTripper
+
+	// Rate количество запросов в секунду, 0 - без ограничений
+	Rate float64
+	// Burst максимальное количество запросов, которое можно выполнить разом
+	Burst   int
+	KeyFunc KeyFunc
+
+	WaitDuration metrics.Histogram
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewTransport(base http.RoundTripper, serviceID string, rate float64, burst int, opts ...Option) *Transport {
+	if burst < 1 {
+		burst = 1
+	}
+	tr := &Transport{
+		base:    base,
+		Rate:    rate,
+		Burst:   burst,
+		KeyFunc: HostKey,
+		WaitDuration: observability.GetMetrics().Histogram(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Время ожидания токена перед запросом",
+			Labels:    []string{"key"},
+		}),
+		buckets: make(map[string]*bucket),
+	}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key := t.KeyFunc(req)
+
+	t.mu.Lock()
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{}
+		t.buckets[key] = b
+	}
+	wait := b.reserve(timeNow(), t.Rate, t.Burst)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		if deadline, ok := ctx.Deadline(); ok && timeNow().Add(wait).After(deadline) {
+			t.cancel(b)
+			return nil, errors.Wrap(ctx, ErrLimitExceeded, keyRateLimit(key))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			t.cancel(b)
+			return nil, errors.Wrap(ctx, ctx.Err(), keyRateLimit(key))
+		case <-timer.C:
+		}
+
+		trace.SpanFromContext(ctx).AddEvent("rate_limit.wait", trace.WithAttributes(
+			attribute.String("rate_limit.key", key),
+			attribute.String("rate_limit.wait", wait.String()),
+		))
+	}
+	t.WaitDuration.Observe(ctx, wait.Seconds(), key)
+
+	return t.base.RoundTrip(req)
+}
+
+func (t *Transport) cancel(b *bucket) {
+	t.mu.Lock()
+	b.cancel(t.Burst)
+	t.mu.Unlock()
+}
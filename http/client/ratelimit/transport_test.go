@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTransport struct {
+	calls int
+}
+
+func (t *MockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls++
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Date(1999, 12, 31, 23, 59, 57, 0, time.UTC)
+	b := &bucket{}
+
+	assert.Equal(t, time.Duration(0), b.reserve(now, 10, 2))
+	assert.Equal(t, time.Duration(0), b.reserve(now, 10, 2))
+	assert.Equal(t, 100*time.Millisecond, b.reserve(now, 10, 2))
+	assert.Equal(t, 200*time.Millisecond, b.reserve(now, 10, 2))
+
+	b.cancel(2)
+	assert.Equal(t, 200*time.Millisecond, b.reserve(now, 10, 2))
+
+	// за секунду накопится не больше burst токенов
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), b.reserve(now, 10, 2))
+	assert.Equal(t, time.Duration(0), b.reserve(now, 10, 2))
+	assert.Equal(t, 100*time.Millisecond, b.reserve(now, 10, 2))
+}
+
+func TestRateLimitDeadline(t *testing.T) {
+	require.NoError(t, observability.Init("test", observability.WithPrometheus(prometheus.NewRegistry())))
+	m := &MockTransport{}
+	tr := NewTransport(m, "test", 1, 1)
+
+	req, err := http.NewRequest("GET", "http://example.com/path", nil)
+	require.NoError(t, err)
+	_, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = tr.RoundTrip(req.WithContext(ctx))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.Equal(t, 1, m.calls)
+
+	// другой хост имеет свой bucket
+	req, err = http.NewRequest("GET", "http://example.org/path", nil)
+	require.NoError(t, err)
+	_, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.calls)
+}
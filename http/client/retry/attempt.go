@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/MikL9/observability/logger"
+	"github.com/MikL9/observability/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const attemptSpanName = "HTTP attempt"
+
+// startAttempt starts a span for a single attempt. Inside an existing trace the
+// span is a child of the current span, so it does not matter whether the tracing
+// transport wraps the retry transport or is wrapped by it: in the first case
+// attempts are children of the request span, in the second the request spans are
+// children of the attempts. Without a trace every attempt starts a new trace
+// linked to the previous attempt.
+func startAttempt(ctx context.Context, attempt, maxAttempt int, prev trace.SpanContext) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithAttributes(
+		attribute.Int(utils.KeyAttempt(attempt).Key, attempt),
+		attribute.Int(utils.KeyMaxAttempt(maxAttempt).Key, maxAttempt),
+	)}
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() && prev.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: prev}))
+	}
+	return otel.Tracer(attemptSpanName).Start(ctx, attemptSpanName, opts...)
+}
+
+// finishAttempt records the attempt outcome and the backoff chosen before the
+// next one, then ends the attempt span. retryable marks an attempt that the
+// retry policy considered failed, retry whether another attempt follows it.
+func (t *Transport) finishAttempt(
+	ctx context.Context,
+	span trace.Span,
+	attempt int,
+	resp *http.Response,
+	err error,
+	retryable, retry bool,
+	wait time.Duration,
+) {
+	failed := err != nil || retryable || (resp != nil && resp.StatusCode >= 500)
+	attrs := []attribute.KeyValue{attribute.Bool("retry", retry)}
+	if resp != nil {
+		attrs = append(attrs, attribute.Int("status", resp.StatusCode))
+	}
+	if wait > 0 {
+		attrs = append(attrs, attribute.Float64("backoff_seconds", wait.Seconds()))
+	}
+
+	if t.LogAttempts && failed {
+		logAttrs := []slog.Attr{
+			utils.KeyAttempt(attempt),
+			utils.KeyMaxAttempt(t.RetryMax),
+			slog.Bool("retry", retry),
+		}
+		if resp != nil {
+			logAttrs = append(logAttrs, slog.Int("status", resp.StatusCode))
+		}
+		if err != nil {
+			logAttrs = append(logAttrs, utils.KeyError(err))
+		}
+		if wait > 0 {
+			logAttrs = append(logAttrs, slog.Float64("backoff_seconds", wait.Seconds()))
+		}
+		logger.Debug(ctx, "http attempt failed", logAttrs...)
+	}
+
+	span.SetAttributes(attrs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if failed {
+		span.SetStatus(codes.Error, "attempt failed")
+	}
+	span.End()
+}
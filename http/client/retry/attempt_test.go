@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAttemptSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	m := MockTransport{
+		results: []Result{
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 200, Body: emptyReadClose}, nil},
+		},
+	}
+	tr := NewTransport(&m, WithRetryWaitMin(10*time.Microsecond), WithRetryWaitMax(10*time.Microsecond))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, "GET", "", nil)
+	_, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	first, second := spans[0], spans[1]
+	assert.Equal(t, attemptSpanName, first.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), first.Parent().SpanID())
+	assert.Equal(t, codes.Error, first.Status().Code)
+	assert.Contains(t, first.Attributes(), attribute.Int("attempt", 0))
+	assert.Contains(t, first.Attributes(), attribute.Int("status", 500))
+	assert.Contains(t, first.Attributes(), attribute.Float64("backoff_seconds", (10 * time.Microsecond).Seconds()))
+
+	assert.Equal(t, attemptSpanName, second.Name())
+	assert.Equal(t, codes.Unset, second.Status().Code)
+	assert.Contains(t, second.Attributes(), attribute.Int("attempt", 1))
+	assert.Contains(t, second.Attributes(), attribute.Int("status", 200))
+}
+
+func TestAttemptSpansRetryExhausted(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	m := MockTransport{
+		results: []Result{
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+		},
+	}
+	tr := NewTransport(&m, WithRetryMax(1), WithRetryWaitMin(10*time.Microsecond), WithRetryWaitMax(10*time.Microsecond))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, "GET", "", nil)
+	_, _ = tr.RoundTrip(req)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("retry", true))
+
+	last := spans[1]
+	assert.Equal(t, codes.Error, last.Status().Code)
+	assert.Contains(t, last.Attributes(), attribute.Bool("retry", false))
+	for _, attr := range last.Attributes() {
+		assert.NotEqual(t, attribute.Key("backoff_seconds"), attr.Key)
+	}
+}
+
+func TestAttemptSpansWithoutTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	m := MockTransport{
+		results: []Result{
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 200, Body: emptyReadClose}, nil},
+		},
+	}
+	tr := NewTransport(&m, WithRetryWaitMin(10*time.Microsecond), WithRetryWaitMax(10*time.Microsecond))
+
+	req, _ := http.NewRequest("GET", "", nil)
+	_, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	first, second := spans[0], spans[1]
+	assert.False(t, first.Parent().IsValid())
+	assert.Contains(t, first.Attributes(), attribute.Int("status", 500))
+	assert.Contains(t, first.Attributes(), attribute.Float64("backoff_seconds", (10 * time.Microsecond).Seconds()))
+
+	assert.False(t, second.Parent().IsValid())
+	require.Len(t, second.Links(), 1)
+	assert.Equal(t, first.SpanContext().SpanID(), second.Links()[0].SpanContext.SpanID())
+	assert.Contains(t, second.Attributes(), attribute.Int("status", 200))
+}
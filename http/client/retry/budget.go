@@ -0,0 +1,76 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+const budgetBuckets = 10
+
+// Budget limits the number of retries to a fraction of recent requests, so
+// that an outage of a dependency does not multiply the load by RetryMax+1.
+// A single Budget may be shared between several transports.
+type Budget struct {
+	// Ratio is the allowed number of retries per original request, e.g. 0.1 allows 10% retries.
+	Ratio float64
+	// MinRetries is the number of retries always allowed within the window,
+	// so that low traffic services can still retry.
+	MinRetries int
+
+	mu       sync.Mutex
+	width    time.Duration
+	starts   [budgetBuckets]time.Time
+	requests [budgetBuckets]int
+	retries  [budgetBuckets]int
+}
+
+// NewBudget creates a Budget counting requests over the given rolling window.
+func NewBudget(ratio float64, minRetries int, window time.Duration) *Budget {
+	width := window / budgetBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &Budget{
+		Ratio:      ratio,
+		MinRetries: minRetries,
+		width:      width,
+	}
+}
+
+// deposit registers an original (not retried) request.
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests[b.bucket(timeNow())]++
+}
+
+// withdraw reports whether a retry is allowed and registers it if so.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := timeNow()
+	var requests, retries int
+	for i, start := range b.starts {
+		if !start.IsZero() && now.Sub(start) < b.width*budgetBuckets {
+			requests += b.requests[i]
+			retries += b.retries[i]
+		}
+	}
+	if float64(retries) >= float64(b.MinRetries)+b.Ratio*float64(requests) {
+		return false
+	}
+	b.retries[b.bucket(now)]++
+	return true
+}
+
+func (b *Budget) bucket(now time.Time) int {
+	start := now.Truncate(b.width)
+	i := int(start.UnixNano()/int64(b.width)) % budgetBuckets
+	if !b.starts[i].Equal(start) {
+		b.starts[i] = start
+		b.requests[i] = 0
+		b.retries[i] = 0
+	}
+	return i
+}
@@ -0,0 +1,233 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/metrics"
+)
+
+const (
+	latencySamples    = 512
+	latencyMinSamples = 20
+	latencyRecompute  = 32
+)
+
+// HedgingTransport sends an additional attempt of an idempotent request if the
+// previous one has not responded within Delay, and returns whichever response
+// comes first. Remaining attempts are cancelled.
+type HedgingTransport struct {
+	base http.RoundTripper
+
+	// Delay is the time to wait before firing a hedge. When Percentile is set,
+	// it is used until enough latencies are observed.
+	Delay time.Duration
+	// Percentile, e.g. 0.95, makes the delay follow the observed latency of
+	// successful attempts. Zero means that Delay is always used.
+	Percentile float64
+	// MaxHedges is the number of additional attempts per request.
+	MaxHedges int
+
+	latencies *latencyTracker
+	metrics   *hedgingMetrics
+}
+
+func NewHedgingTransport(base http.RoundTripper, opts ...HedgingOption) *HedgingTransport {
+	tr := &HedgingTransport{
+		base:      base,
+		Delay:     100 * time.Millisecond,
+		MaxHedges: 1,
+		latencies: &latencyTracker{},
+	}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
+}
+
+type hedgeResult struct {
+	resp    *http.Response
+	err     error
+	attempt int
+	latency time.Duration
+}
+
+func (t *HedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) || t.MaxHedges <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	body, _, err := getBodyReaderAndContentLength(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan hedgeResult, t.MaxHedges+1)
+	cancels := make([]context.CancelFunc, 0, t.MaxHedges+1)
+	cancelAll := func(except int) {
+		for i, cancel := range cancels {
+			if i != except {
+				cancel()
+			}
+		}
+	}
+	fire := func() error {
+		ctx, cancel := context.WithCancel(req.Context())
+		attemptReq := req.Clone(ctx)
+		if err := rewindBody(attemptReq, body); err != nil {
+			cancel()
+			return err
+		}
+		cancels = append(cancels, cancel)
+		go func(attempt int) {
+			timeStart := time.Now()
+			resp, err := t.base.RoundTrip(attemptReq)
+			results <- hedgeResult{resp: resp, err: err, attempt: attempt, latency: time.Since(timeStart)}
+		}(len(cancels) - 1)
+		return nil
+	}
+
+	if err := fire(); err != nil {
+		return nil, err
+	}
+	inFlight := 1
+
+	delay := t.delay()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) > t.MaxHedges {
+				continue
+			}
+			if err := fire(); err != nil {
+				lastErr = err
+				continue
+			}
+			inFlight++
+			t.metrics.fired(req.Context())
+			timer.Reset(delay)
+		case res := <-results:
+			inFlight--
+			if res.err != nil {
+				lastErr = res.err
+				continue
+			}
+
+			cancelAll(res.attempt)
+			go drainHedges(results, inFlight)
+			t.latencies.observe(res.latency)
+			if res.attempt > 0 {
+				t.metrics.won(req.Context())
+			}
+			if res.resp.Body != nil {
+				res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: cancels[res.attempt]}
+			} else {
+				cancels[res.attempt]()
+			}
+			return res.resp, nil
+		}
+	}
+
+	cancelAll(-1)
+	return nil, lastErr
+}
+
+func (t *HedgingTransport) delay() time.Duration {
+	if t.Percentile <= 0 {
+		return t.Delay
+	}
+	if d, ok := t.latencies.percentile(t.Percentile); ok {
+		return d
+	}
+	return t.Delay
+}
+
+// drainHedges closes responses of the attempts that lost the race.
+func drainHedges(results <-chan hedgeResult, inFlight int) {
+	for ; inFlight > 0; inFlight-- {
+		res := <-results
+		if res.err == nil && res.resp.Body != nil {
+			res.resp.Body.Close()
+		}
+	}
+}
+
+// latencyTracker keeps a ring buffer of the latest latencies.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples [latencySamples]time.Duration
+	count   int
+	next    int
+
+	cached   time.Duration
+	cachedAt int
+	cachedP  float64
+}
+
+func (l *latencyTracker) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencySamples
+	l.count++
+}
+
+func (l *latencyTracker) percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count < latencyMinSamples {
+		return 0, false
+	}
+	if l.cachedAt != 0 && l.cachedP == p && l.count-l.cachedAt < latencyRecompute {
+		return l.cached, true
+	}
+
+	sorted := slices.Clone(l.samples[:min(l.count, latencySamples)])
+	slices.Sort(sorted)
+	i := int(p * float64(len(sorted)-1))
+	l.cached, l.cachedAt, l.cachedP = sorted[min(max(i, 0), len(sorted)-1)], l.count, p
+	return l.cached, true
+}
+
+type hedgingMetrics struct {
+	firedTotal metrics.Counter
+	wonTotal   metrics.Counter
+}
+
+func newHedgingMetrics(serviceID string) *hedgingMetrics {
+	provider := observability.GetMetrics()
+	return &hedgingMetrics{
+		firedTotal: provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "hedges_fired_total",
+			Help:      "Количество отправленных дополнительных (hedged) запросов",
+		}),
+		wonTotal: provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "hedges_won_total",
+			Help:      "Количество запросов, в которых первым ответил дополнительный запрос",
+		}),
+	}
+}
+
+func (m *hedgingMetrics) fired(ctx context.Context) {
+	if m != nil {
+		m.firedTotal.Add(ctx, 1)
+	}
+}
+
+func (m *hedgingMetrics) won(ctx context.Context) {
+	if m != nil {
+		m.wonTotal.Add(ctx, 1)
+	}
+}
@@ -0,0 +1,101 @@
+package retry
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hedgeMockTransport отвечает с задержкой, заданной для каждой попытки
+type hedgeMockTransport struct {
+	mu     sync.Mutex
+	delays []time.Duration
+	bodies []string
+	calls  int
+}
+
+func (t *hedgeMockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	i := t.calls
+	t.calls++
+	t.mu.Unlock()
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+	select {
+	case <-time.After(t.delays[i]):
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(t.bodies[i] + string(body)))}, nil
+}
+
+func TestHedgingWon(t *testing.T) {
+	m := &hedgeMockTransport{
+		delays: []time.Duration{time.Second, 0},
+		bodies: []string{"first ", "second "},
+	}
+	tr := NewHedgingTransport(m, WithHedgeDelay(10*time.Millisecond))
+
+	req, err := http.NewRequest("GET", "/path", strings.NewReader("body"))
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "second body", string(body))
+	m.mu.Lock()
+	assert.Equal(t, 2, m.calls)
+	m.mu.Unlock()
+}
+
+func TestHedgingNotFired(t *testing.T) {
+	m := &hedgeMockTransport{
+		delays: []time.Duration{0},
+		bodies: []string{"first"},
+	}
+	tr := NewHedgingTransport(m, WithHedgeDelay(time.Second))
+
+	req, err := http.NewRequest("GET", "/path", nil)
+	require.NoError(t, err)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, 1, m.calls)
+
+	// неидемпотентные запросы не дублируются
+	m.calls = 0
+	tr.Delay = 0
+	req, err = http.NewRequest("POST", "/path", nil)
+	require.NoError(t, err)
+	resp, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, 1, m.calls)
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	l := &latencyTracker{}
+	_, ok := l.percentile(0.9)
+	assert.False(t, ok)
+
+	for i := 1; i <= 100; i++ {
+		l.observe(time.Duration(i) * time.Millisecond)
+	}
+	d, ok := l.percentile(0.9)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Millisecond, d)
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/metrics"
+)
+
+type retryMetrics struct {
+	attemptsTotal        metrics.Counter
+	retriesTotal         metrics.Counter
+	budgetExhaustedTotal metrics.Counter
+}
+
+func newMetrics(serviceID string) *retryMetrics {
+	provider := observability.GetMetrics()
+	return &retryMetrics{
+		attemptsTotal: provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "retry_attempts_total",
+			Help:      "Общее количество попыток выполнения запросов",
+		}),
+		retriesTotal: provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "retry_retries_total",
+			Help:      "Общее количество повторных попыток",
+		}),
+		budgetExhaustedTotal: provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "retry_budget_exhausted_total",
+			Help:      "Количество повторов, отклоненных из-за исчерпания бюджета",
+		}),
+	}
+}
+
+func (m *retryMetrics) attempt(ctx context.Context) {
+	if m != nil {
+		m.attemptsTotal.Add(ctx, 1)
+	}
+}
+
+func (m *retryMetrics) retry(ctx context.Context) {
+	if m != nil {
+		m.retriesTotal.Add(ctx, 1)
+	}
+}
+
+func (m *retryMetrics) budgetExhausted(ctx context.Context) {
+	if m != nil {
+		m.budgetExhaustedTotal.Add(ctx, 1)
+	}
+}
@@ -51,3 +51,68 @@ func WithRetryWaitMax(waitMax time.Duration) Option {
 		t.RetryWaitMax = waitMax
 	}
 }
+
+// WithAttemptTimeout limits the duration of a single attempt.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(t *Transport) {
+		t.AttemptTimeout = timeout
+	}
+}
+
+// WithBudget limits retries to a fraction of recent requests.
+func WithBudget(b *Budget) Option {
+	return func(t *Transport) {
+		t.Budget = b
+	}
+}
+
+// WithRetryNonIdempotent allows retrying non-idempotent requests on any retryable error.
+func WithRetryNonIdempotent() Option {
+	return func(t *Transport) {
+		t.RetryNonIdempotent = true
+	}
+}
+
+// WithMetrics enables attempts, retries and exhausted budget counters.
+func WithMetrics(serviceID string) Option {
+	return func(t *Transport) {
+		t.metrics = newMetrics(serviceID)
+	}
+}
+
+type HedgingOption func(*HedgingTransport)
+
+// WithHedgeDelay sets the time to wait before firing a hedge.
+func WithHedgeDelay(delay time.Duration) HedgingOption {
+	return func(t *HedgingTransport) {
+		t.Delay = delay
+	}
+}
+
+// WithHedgePercentile makes the hedge delay follow the given percentile of observed latencies.
+func WithHedgePercentile(percentile float64) HedgingOption {
+	return func(t *HedgingTransport) {
+		t.Percentile = percentile
+	}
+}
+
+// WithMaxHedges sets the number of additional attempts per request.
+func WithMaxHedges(n int) HedgingOption {
+	return func(t *HedgingTransport) {
+		t.MaxHedges = n
+	}
+}
+
+// WithHedgingMetrics enables hedges fired and won counters.
+func WithHedgingMetrics(serviceID string) HedgingOption {
+	return func(t *HedgingTransport) {
+		t.metrics = newHedgingMetrics(serviceID)
+	}
+}
+
+// WithAttemptLog enables a debug log record for every failed attempt.
+func WithAttemptLog() Option {
+	return func(t *Transport) {
+		t.LogAttempts = true
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/MikL9/observability/storage"
 	"github.com/MikL9/observability/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
@@ -41,6 +42,20 @@ type Transport struct {
 	RetryMax     int
 	RetryWaitMin time.Duration
 	RetryWaitMax time.Duration
+
+	// AttemptTimeout limits the duration of a single attempt. Zero means
+	// that only the request context deadline applies.
+	AttemptTimeout time.Duration
+	// Budget limits retries to a fraction of recent requests. Nil means no limit.
+	Budget *Budget
+	// RetryNonIdempotent allows retrying non-idempotent requests (POST, PATCH)
+	// without an Idempotency-Key header on any retryable error, not only on
+	// connection errors.
+	RetryNonIdempotent bool
+	// LogAttempts enables a debug log record for every failed attempt.
+	LogAttempts bool
+
+	metrics *retryMetrics
 }
 
 func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
@@ -69,34 +84,59 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.Budget != nil {
+		t.Budget.deposit()
+	}
+	var prevAttempt trace.SpanContext
 	for i := 0; ; i++ {
 		ctx = storage.SetContextAttr(ctx,
 			utils.KeyAttempt(i),
 			utils.KeyMaxAttempt(t.RetryMax),
 		)
-		req = req.Clone(ctx)
+		attemptCtx, cancel := ctx, context.CancelFunc(nil)
+		if t.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, t.AttemptTimeout)
+		}
+		attemptCtx, span := startAttempt(attemptCtx, i, t.RetryMax, prevAttempt)
+		prevAttempt = span.SpanContext()
+		req = req.Clone(attemptCtx)
 		doErr, respErr, prepareErr = nil, nil, nil
 		attempt++
+		t.metrics.attempt(ctx)
 
 		// Always rewind the request body when non-nil.
 		if err := rewindBody(req, body); err != nil {
+			t.finishAttempt(ctx, span, i, nil, err, false, false, 0)
+			releaseAttempt(cancel)
 			return nil, err
 		}
 
 		if t.PrepareRequest != nil {
 			if prepareErr = t.PrepareRequest(req.Context(), req, i > 0); prepareErr != nil {
+				t.finishAttempt(ctx, span, i, nil, prepareErr, false, false, 0)
+				releaseAttempt(cancel)
 				break
 			}
 		}
 
 		// Attempt the request
 		resp, doErr = t.base.RoundTrip(req)
+		// The attempt context lives until the response body is closed.
+		if cancel != nil && doErr == nil && resp != nil && resp.Body != nil {
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			releaseAttempt(cancel)
+		}
 
-		// Check if we should continue with retries.
-		shouldRetry, checkErr = t.CheckRetry(req.Context(), resp, doErr)
+		// Check if we should continue with retries. The parent context is used,
+		// so that an expired attempt timeout is treated as a retryable error.
+		shouldRetry, checkErr = t.CheckRetry(ctx, resp, doErr)
 		if !shouldRetry && doErr == nil && t.ResponseHandler != nil {
 			respErr = t.ResponseHandler(resp)
-			shouldRetry, checkErr = t.CheckRetry(req.Context(), resp, respErr)
+			shouldRetry, checkErr = t.CheckRetry(ctx, resp, respErr)
+		}
+		if shouldRetry && !t.RetryNonIdempotent && !isIdempotent(req) && !isConnectionError(doErr) {
+			shouldRetry = false
 		}
 
 		err = doErr
@@ -105,6 +145,7 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 
 		if !shouldRetry {
+			t.finishAttempt(ctx, span, i, resp, err, false, false, 0)
 			break
 		}
 
@@ -112,20 +153,30 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		// we're breaking out
 		remain := t.RetryMax - i
 		if remain <= 0 {
+			t.finishAttempt(ctx, span, i, resp, err, true, false, 0)
 			break
 		}
 
+		if t.Budget != nil && !t.Budget.withdraw() {
+			t.metrics.budgetExhausted(ctx)
+			t.finishAttempt(ctx, span, i, resp, err, true, false, 0)
+			break
+		}
+		t.metrics.retry(ctx)
+
+		wait := t.Backoff(t.RetryWaitMin, t.RetryWaitMax, i, resp)
+		t.finishAttempt(ctx, span, i, resp, err, true, true, wait)
+
 		// We're going to retry, consume any response to reuse the connection.
 		if doErr == nil {
-			drainBody(req.Context(), resp.Body)
+			drainBody(ctx, resp.Body)
 		}
 
-		wait := t.Backoff(t.RetryWaitMin, t.RetryWaitMax, i, resp)
 		timer := time.NewTimer(wait)
 		select {
-		case <-req.Context().Done():
+		case <-ctx.Done():
 			timer.Stop()
-			return nil, req.Context().Err()
+			return nil, ctx.Err()
 		case <-timer.C:
 		}
 	}
@@ -87,3 +87,79 @@ func TestFailedAfterRetries(t *testing.T) {
 		&http.Response{StatusCode: 500, Body: bodyFromString("test")},
 		resp)
 }
+
+func TestNonIdempotentNotRetried(t *testing.T) {
+	m := MockTransport{
+		results: []Result{
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 200, Body: emptyReadClose}, nil},
+		},
+	}
+	tr := NewTransport(&m, WithRetryWaitMin(10*time.Microsecond), WithRetryWaitMax(10*time.Microsecond))
+
+	req, _ := http.NewRequest("POST", "/path/to/", bodyFromString("some content"))
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Len(t, m.results, 1)
+
+	req.Header.Set("Idempotency-Key", "1")
+	m.results = []Result{
+		{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+		{&http.Response{StatusCode: 200, Body: emptyReadClose}, nil},
+	}
+	resp, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestBudgetExhausted(t *testing.T) {
+	m := MockTransport{
+		results: []Result{
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 500, Body: emptyReadClose}, nil},
+			{&http.Response{StatusCode: 200, Body: emptyReadClose}, nil},
+		},
+	}
+	tr := NewTransport(&m,
+		WithRetryWaitMin(10*time.Microsecond),
+		WithRetryWaitMax(10*time.Microsecond),
+		WithBudget(NewBudget(0, 1, time.Minute)),
+	)
+
+	req, _ := http.NewRequest("GET", "", nil)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Len(t, m.results, 2, "бюджет позволяет только один повтор")
+}
+
+type slowTransport struct {
+	attempts int
+}
+
+func (t *slowTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.attempts++
+	if t.attempts == 1 {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}
+	return &http.Response{StatusCode: 200, Body: emptyReadClose}, nil
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	m := &slowTransport{}
+	tr := NewTransport(m,
+		WithRetryWaitMin(10*time.Microsecond),
+		WithRetryWaitMax(10*time.Microsecond),
+		WithAttemptTimeout(10*time.Millisecond),
+	)
+
+	req, _ := http.NewRequest("GET", "", nil)
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 2, m.attempts)
+	require.NoError(t, resp.Body.Close())
+}
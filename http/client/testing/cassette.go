@@ -0,0 +1,120 @@
+// Package testing позволяет записывать запросы клиента в golden-файлы и
+// воспроизводить их в тестах без обращения к реальным сервисам.
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MikL9/observability/hide"
+)
+
+// Cassette содержимое golden-файла
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// LoadCassette читает golden-файл
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("unmarshal cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save записывает golden-файл, создавая недостающие директории
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// maskBody маскирует чувствительные поля тела так же, как это делает логгер
+func maskBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if masked, err := hide.MaskSensitiveJSONFields(body); err == nil && masked != "" {
+		return masked
+	}
+	return string(body)
+}
+
+func maskHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	masked := make(http.Header, len(header))
+	for key, values := range header {
+		for _, v := range values {
+			masked.Add(key, hide.Hide(strings.ToLower(key), v))
+		}
+	}
+	return masked
+}
+
+func maskURL(u *url.URL) string {
+	masked := *u
+	query := u.Query()
+	for key, values := range query {
+		for i, v := range values {
+			values[i] = hide.Hide(key, v)
+		}
+		query[key] = values
+	}
+	masked.RawQuery = query.Encode()
+	masked.User = nil
+	return masked.String()
+}
+
+// canonicalBody приводит тело к виду, не зависящему от форматирования и порядка полей JSON
+func canonicalBody(body string) string {
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return strings.TrimSpace(body)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return strings.TrimSpace(body)
+	}
+	return strings.TrimSpace(buf.String())
+}
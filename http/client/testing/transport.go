@@ -0,0 +1,184 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	gotesting "testing"
+
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/utils"
+)
+
+// ErrNoInteraction возвращается Replayer, если в golden-файле нет подходящего запроса
+var ErrNoInteraction = errors.NewConstError("no recorded interaction matches request")
+
+// UpdateEnv переменная окружения, при наличии которой Golden перезаписывает golden-файлы
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Recorder выполняет запросы через rt и запоминает пары запрос/ответ
+// с замаскированными через hide чувствительными данными
+type Recorder struct {
+	rt http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(rt http.RoundTripper) *Recorder {
+	return &Recorder{rt: rt}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody := utils.GetRequestBodyCopy(req)
+
+	resp, err := r.rt.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	var respBody []byte
+	if resp.Body != nil {
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    maskURL(req.URL),
+			Header: maskHeader(req.Header),
+			Body:   maskBody(reqBody),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: maskHeader(resp.Header),
+			Body:   maskBody(respBody),
+		},
+	})
+	return resp, nil
+}
+
+// Cassette возвращает копию записанных взаимодействий
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save записывает взаимодействия в golden-файл
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// Replayer отвечает на запросы из golden-файла. Запрос сопоставляется по методу,
+// пути и каноническому телу, каждое взаимодействие используется один раз в порядке записи
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+}
+
+// NewReplayerFromFile создает Replayer из golden-файла
+func NewReplayerFromFile(path string) (*Replayer, error) {
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c), nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := canonicalBody(maskBody(utils.GetRequestBodyCopy(req)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || !matches(interaction.Request, req, body) {
+			continue
+		}
+		r.used[i] = true
+
+		resp := interaction.Response
+		header := resp.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        strconv.Itoa(resp.Status) + " " + http.StatusText(resp.Status),
+			StatusCode:    resp.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(resp.Body)),
+			ContentLength: int64(len(resp.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.Path)
+}
+
+// Unused возвращает взаимодействия, которые так и не были запрошены
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func matches(recorded Request, req *http.Request, body string) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil || u.Path != req.URL.Path {
+		return false
+	}
+	return canonicalBody(recorded.Body) == body
+}
+
+// Golden возвращает транспорт для теста: при заданной переменной окружения UPDATE_GOLDEN
+// запросы выполняются через rt и по завершении теста записываются в path,
+// иначе ответы воспроизводятся из path без обращения к сети
+func Golden(t gotesting.TB, rt http.RoundTripper, path string) http.RoundTripper {
+	t.Helper()
+	if _, ok := os.LookupEnv(UpdateEnv); ok {
+		rec := NewRecorder(rt)
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Errorf("save golden file: %v", err)
+			}
+		})
+		return rec
+	}
+
+	rep, err := NewReplayerFromFile(path)
+	if err != nil {
+		t.Fatalf("load golden file: %v", err)
+	}
+	return rep
+}
@@ -0,0 +1,120 @@
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MikL9/observability/hide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	hide.SetDefaultConverter(hide.NewConverter(
+		hide.WithFullExcludeRule([]string{"token", "password", "authorization"}),
+	))
+	defer hide.SetDefaultConverter(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1,"path":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(http.DefaultTransport)
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/users?token=secret", strings.NewReader(`{"name":"bob","password":"qwerty"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := rec.RoundTrip(req)
+	require.NoError(t, err)
+	recorded, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	path := filepath.Join(t.TempDir(), "golden", "users.json")
+	require.NoError(t, rec.Save(path))
+
+	cassette, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 1)
+	interaction := cassette.Interactions[0]
+	assert.NotContains(t, interaction.Request.URL, "secret")
+	assert.NotContains(t, interaction.Request.Header.Get("Authorization"), "secret")
+	assert.NotContains(t, interaction.Request.Body, "qwerty")
+
+	rep, err := NewReplayerFromFile(path)
+	require.NoError(t, err)
+
+	// порядок полей и форматирование тела не влияют на сопоставление
+	req, err = http.NewRequest(http.MethodPost, "http://other.host/users", bytes.NewBufferString(`{ "password": "qwerty", "name": "bob" }`))
+	require.NoError(t, err)
+	resp, err = rep.RoundTrip(req)
+	require.NoError(t, err)
+	replayed, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, string(recorded), string(replayed))
+	assert.Empty(t, rep.Unused())
+
+	// каждое взаимодействие воспроизводится один раз
+	req, err = http.NewRequest(http.MethodPost, "http://other.host/users", strings.NewReader(`{"name":"bob","password":"qwerty"}`))
+	require.NoError(t, err)
+	_, err = rep.RoundTrip(req)
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+}
+
+func TestReplayerMismatch(t *testing.T) {
+	rep := NewReplayer(&Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "http://example.com/a"},
+		Response: Response{Status: http.StatusOK, Body: "ok"},
+	}}})
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+	}{
+		{"method", http.MethodDelete, "http://example.com/a", ""},
+		{"path", http.MethodGet, "http://example.com/b", ""},
+		{"body", http.MethodGet, "http://example.com/a", "payload"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			_, err = rep.RoundTrip(req)
+			assert.ErrorIs(t, err, ErrNoInteraction)
+		})
+	}
+	assert.Len(t, rep.Unused(), 1)
+}
+
+func TestGoldenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	require.NoError(t, (&Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "http://example.com/ping"},
+		Response: Response{Status: http.StatusOK, Body: "pong"},
+	}}}).Save(path))
+
+	t.Setenv(UpdateEnv, "")
+	require.NoError(t, os.Unsetenv(UpdateEnv))
+
+	rt := Golden(t, http.DefaultTransport, path)
+	resp, err := (&http.Client{Transport: rt}).Get("http://example.com/ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(body))
+}
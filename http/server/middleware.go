@@ -8,10 +8,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/MikL9/observability"
 	"github.com/MikL9/observability/logger"
 	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/metrics"
 	"github.com/MikL9/observability/storage"
 	"github.com/MikL9/observability/tracing"
 	"github.com/MikL9/observability/utils"
@@ -24,8 +24,8 @@ type (
 	}
 
 	serverMetrics struct {
-		totalRequests   *prometheus.CounterVec
-		requestDuration *prometheus.HistogramVec
+		totalRequests   metrics.Counter
+		requestDuration metrics.Histogram
 	}
 
 	Middleware struct {
@@ -101,9 +101,9 @@ func (m *Middleware) HTTPMiddlewareWithParams(next http.Handler) http.Handler {
 						utils.KeyDuration(timeStart),
 						utils.KeyPanic(fmt.Sprint(rr), 4))
 
-					m.metrics.totalRequests.WithLabelValues(r.URL.Path, appType, appVersion, strconv.Itoa(http.StatusInternalServerError)).Add(1)
-					m.metrics.requestDuration.WithLabelValues(r.URL.Path, appType, appVersion, strconv.Itoa(http.StatusInternalServerError)).
-						Observe(time.Since(timeStart).Seconds())
+					m.metrics.totalRequests.Add(ctx, 1, r.URL.Path, appType, appVersion, strconv.Itoa(http.StatusInternalServerError))
+					m.metrics.requestDuration.Observe(ctx, time.Since(timeStart).Seconds(),
+						r.URL.Path, appType, appVersion, strconv.Itoa(http.StatusInternalServerError))
 				}
 			}()
 		}
@@ -122,9 +122,9 @@ func (m *Middleware) HTTPMiddlewareWithParams(next http.Handler) http.Handler {
 		}
 
 		if m.needToMetrics {
-			m.metrics.totalRequests.WithLabelValues(r.URL.Path, appType, appVersion, strconv.Itoa(sw.responseData.Status)).Add(1)
-			m.metrics.requestDuration.WithLabelValues(r.URL.Path, appType, appVersion, strconv.Itoa(sw.responseData.Status)).
-				Observe(time.Since(timeStart).Seconds())
+			m.metrics.totalRequests.Add(ctx, 1, r.URL.Path, appType, appVersion, strconv.Itoa(sw.responseData.Status))
+			m.metrics.requestDuration.Observe(ctx, time.Since(timeStart).Seconds(),
+				r.URL.Path, appType, appVersion, strconv.Itoa(sw.responseData.Status))
 		}
 		if m.needToTracing {
 			ctx = storage.SetContextAttr(ctx, attrs...)
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MikL9/observability/observabilitytest"
+)
+
+func TestMiddlewareMetricsExemplar(t *testing.T) {
+	sink := observabilitytest.New(t)
+	m := NewHTTPMiddleware(WithTracing(), WithMetrics("api"))
+	handler := m.HTTPMiddlewareWithParams(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	span := sink.AssertSpan(t, "GET /users")
+	require.NotNil(t, span)
+
+	families, err := sink.Registry.Gather()
+	require.NoError(t, err)
+	var exemplarTraceID string
+	for _, family := range families {
+		if family.GetName() != "http_api_request_duration_seconds" {
+			continue
+		}
+		for _, b := range family.GetMetric()[0].GetHistogram().GetBucket() {
+			if e := b.GetExemplar(); e != nil {
+				exemplarTraceID = e.GetLabel()[0].GetValue()
+			}
+		}
+	}
+	assert.Equal(t, span.SpanContext().TraceID().String(), exemplarTraceID)
+	assert.Equal(t, 1.0, sink.CounterValue(t, "http_api_requests_total", map[string]string{
+		"path": "/users", "app_type": "", "app_version": "", "status_code": "200",
+	}))
+}
@@ -4,9 +4,8 @@ import (
 	"regexp"
 	"sync/atomic"
 
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/metrics"
 	"github.com/MikL9/observability/utils"
 )
 
@@ -43,25 +42,21 @@ func WithMetrics(serviceID string) Option {
 	return func(m *Middleware) {
 		serviceID = utils.ToSnakeCase(serviceID)
 		m.needToMetrics = true
-		totalRequests := promauto.With(observability.GetRegisterer()).NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: "http",
-				Subsystem: serviceID,
-				Name:      "requests_total",
-				Help:      "Total number of HTTP requests",
-			},
-			[]string{"path", "app_type", "app_version", "status_code"},
-		)
-		requestDuration := promauto.With(observability.GetRegisterer()).NewHistogramVec(
-			prometheus.HistogramOpts{
-				Namespace: "http",
-				Subsystem: serviceID,
-				Name:      "request_duration_seconds",
-				Help:      "Duration of HTTP requests in seconds",
-				Buckets:   prometheus.DefBuckets,
-			},
-			[]string{"path", "app_type", "app_version", "status_code"},
-		)
+		provider := observability.GetMetrics()
+		totalRequests := provider.Counter(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests",
+			Labels:    []string{"path", "app_type", "app_version", "status_code"},
+		})
+		requestDuration := provider.Histogram(metrics.Opts{
+			Namespace: "http",
+			Subsystem: serviceID,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests in seconds",
+			Labels:    []string{"path", "app_type", "app_version", "status_code"},
+		})
 
 		m.metrics = &serverMetrics{
 			totalRequests:   totalRequests,
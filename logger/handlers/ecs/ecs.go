@@ -0,0 +1,103 @@
+// Package ecs обработчик в формате Elastic Common Schema
+package ecs
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/MikL9/observability/utils"
+)
+
+const (
+	Version = "8.11.0"
+
+	TimestampKey  = "@timestamp"
+	LevelKey      = "log.level"
+	MessageKey    = "message"
+	OriginKey     = "log.origin"
+	ErrorKey      = "error.message"
+	StacktraceKey = "error.stack_trace"
+	TraceIDKey    = "trace.id"
+	SpanIDKey     = "span.id"
+	UserIDKey     = "user.id"
+)
+
+// keys соответствие известных ключей библиотеки полям ECS
+var keys = map[string]string{
+	slog.TimeKey:            TimestampKey,
+	slog.LevelKey:           LevelKey,
+	slog.MessageKey:         MessageKey,
+	utils.ErrorKey:          ErrorKey,
+	utils.StacktraceKey:     StacktraceKey,
+	utils.TraceIDKey:        TraceIDKey,
+	utils.SpanIDKey:         SpanIDKey,
+	string(utils.UserIDKey): UserIDKey,
+}
+
+type Option struct {
+	// log level (default: debug)
+	Level slog.Leveler
+	// Writer (default: os.Stdout)
+	Writer io.Writer
+	// ServiceName значение service.name
+	ServiceName string
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// NewHandler создает JSON обработчик с именами полей ECS для известных ключей библиотеки
+func (o Option) NewHandler() slog.Handler {
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.Writer == nil {
+		o.Writer = os.Stdout
+	}
+	attrs := []slog.Attr{slog.String("ecs.version", Version)}
+	if o.ServiceName != "" {
+		attrs = append(attrs, slog.String("service.name", o.ServiceName))
+	}
+	return slog.NewJSONHandler(o.Writer, &slog.HandlerOptions{
+		Level:       o.Level,
+		AddSource:   o.AddSource,
+		ReplaceAttr: replaceAttr(o.ReplaceAttr),
+	}).WithAttrs(attrs)
+}
+
+func replaceAttr(next func([]string, slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			a = convert(a)
+		}
+		if next == nil {
+			return a
+		}
+		return next(groups, a)
+	}
+}
+
+func convert(a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(strings.ToLower(level.String()))
+		}
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.Group(OriginKey,
+				slog.String("function", src.Function),
+				slog.Group("file", slog.String("name", src.File), slog.Int("line", src.Line)),
+			)
+		}
+	case utils.ErrorKey:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(err.Error())
+		}
+	}
+	if key, ok := keys[a.Key]; ok {
+		a.Key = key
+	}
+	return a
+}
@@ -0,0 +1,221 @@
+// Package gelf обработчик в формате GELF 1.1 для Graylog
+package gelf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability/utils"
+)
+
+const (
+	Version = "1.1"
+
+	// ErrorKey поле сообщения ошибки вместо utils.ErrorKey
+	ErrorKey = "_error"
+)
+
+// уровни syslog
+const (
+	levelError   = 3
+	levelWarning = 4
+	levelInfo    = 6
+	levelDebug   = 7
+)
+
+// invalidKeyChars символы, недопустимые в именах дополнительных полей
+var invalidKeyChars = regexp.MustCompile(`[^\w.\-]`)
+
+type Option struct {
+	// log level (default: debug)
+	Level slog.Leveler
+	// Writer (default: os.Stdout)
+	Writer io.Writer
+	// Host значение host (default: os.Hostname)
+	Host string
+	// NullDelimiter разделяет сообщения нулевым байтом вместо перевода строки, как требует GELF TCP
+	NullDelimiter bool
+	AddSource     bool
+}
+
+// NewHandler создает обработчик GELF. Атрибуты записываются дополнительными полями с префиксом "_",
+// группы разворачиваются через "_", utils.StacktraceKey записывается в full_message,
+// utils.ErrorKey в _error, trace_id, span_id и user_id в _trace_id, _span_id и _user_id
+func (o Option) NewHandler() *Handler {
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.Writer == nil {
+		o.Writer = os.Stdout
+	}
+	if o.Host == "" {
+		o.Host, _ = os.Hostname()
+	}
+	return &Handler{option: o, m: &sync.Mutex{}}
+}
+
+type Handler struct {
+	option Option
+	// attrs атрибуты из WithAttrs с ключами, уже развернутыми по группам
+	attrs  []slog.Attr
+	prefix string
+	m      *sync.Mutex
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.option.Level.Level()
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	msg := map[string]any{
+		"version":       Version,
+		"host":          h.option.Host,
+		"short_message": r.Message,
+		"timestamp":     float64(r.Time.UnixMilli()) / 1e3,
+		"level":         syslogLevel(r.Level),
+	}
+	if h.option.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		msg["_file"] = frame.File
+		msg["_line"] = frame.Line
+	}
+
+	attrs := slices.Clone(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = flatten(attrs, h.prefix, a)
+		return true
+	})
+	for _, a := range attrs {
+		switch a.Key {
+		case utils.StacktraceKey:
+			msg["full_message"] = a.Value.String()
+		case utils.ErrorKey:
+			msg[ErrorKey] = fieldValue(a.Value)
+		default:
+			msg[fieldName(a.Key)] = fieldValue(a.Value)
+		}
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if h.option.NullDelimiter {
+		b = append(b, 0)
+	} else {
+		b = append(b, '\n')
+	}
+
+	h.m.Lock()
+	defer h.m.Unlock()
+	_, err = h.option.Writer.Write(b)
+	return err
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		next.attrs = flatten(next.attrs, h.prefix, a)
+	}
+	return &next
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.prefix = joinKey(h.prefix, name)
+	return &next
+}
+
+func syslogLevel(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return levelError
+	case level >= slog.LevelWarn:
+		return levelWarning
+	case level >= slog.LevelInfo:
+		return levelInfo
+	default:
+		return levelDebug
+	}
+}
+
+// fieldName имя дополнительного поля. Поле _id зарезервировано GELF
+func fieldName(key string) string {
+	key = "_" + invalidKeyChars.ReplaceAllString(key, "_")
+	if key == "_id" {
+		return "__id"
+	}
+	return key
+}
+
+// fieldValue значение дополнительного поля, GELF допускает только строки и числа
+func fieldValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return strconv.FormatBool(v.Bool())
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch val := v.Any().(type) {
+		case error:
+			return val.Error()
+		case fmt.Stringer:
+			return val.String()
+		}
+		b, err := json.Marshal(v.Any())
+		if err != nil {
+			return fmt.Sprintf("%+v", v.Any())
+		}
+		return string(b)
+	default:
+		return v.String()
+	}
+}
+
+func flatten(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := joinKey(prefix, a.Key)
+		for _, nested := range a.Value.Group() {
+			attrs = flatten(attrs, groupPrefix, nested)
+		}
+		return attrs
+	}
+	a.Key = joinKey(prefix, a.Key)
+	return append(attrs, a)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if key == "" {
+		return prefix
+	}
+	return prefix + "_" + key
+}
@@ -0,0 +1,62 @@
+// Package logfmt обработчик в формате logfmt (key=value), например для Loki
+package logfmt
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/MikL9/observability/utils"
+)
+
+// ErrorKey ключ сообщения ошибки вместо utils.ErrorKey
+const ErrorKey = "error"
+
+type Option struct {
+	// log level (default: debug)
+	Level slog.Leveler
+	// Writer (default: os.Stdout)
+	Writer      io.Writer
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// NewHandler создает обработчик поверх slog.TextHandler. Уровень выводится в нижнем регистре,
+// ошибка из utils.ErrorKey выводится строкой под ключом error, trace_id, span_id, user_id
+// и stacktrace сохраняют свои ключи, многострочные значения экранируются
+func (o Option) NewHandler() slog.Handler {
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.Writer == nil {
+		o.Writer = os.Stdout
+	}
+	return slog.NewTextHandler(o.Writer, &slog.HandlerOptions{
+		Level:       o.Level,
+		AddSource:   o.AddSource,
+		ReplaceAttr: replaceAttr(o.ReplaceAttr),
+	})
+}
+
+func replaceAttr(next func([]string, slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch a.Key {
+			case slog.LevelKey:
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a.Value = slog.StringValue(strings.ToLower(level.String()))
+				}
+			case utils.ErrorKey:
+				a.Key = ErrorKey
+				if err, ok := a.Value.Any().(error); ok {
+					a.Value = slog.StringValue(err.Error())
+				}
+			}
+		}
+		if next == nil {
+			return a
+		}
+		return next(groups, a)
+	}
+}
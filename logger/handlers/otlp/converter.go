@@ -0,0 +1,106 @@
+package otlp
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MikL9/observability/hide"
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/utils"
+	"go.opentelemetry.io/otel/log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+var timeNow = time.Now
+
+func convertRecord(record slog.Record, handlerAttrs []log.KeyValue, group string) log.Record {
+	var rec log.Record
+	rec.SetTimestamp(record.Time)
+	rec.SetObservedTimestamp(timeNow())
+	rec.SetSeverity(log.Severity(record.Level + 9))
+	rec.SetSeverityText(record.Level.String())
+	rec.SetBody(log.StringValue(record.Message))
+
+	attrs := make([]log.KeyValue, 0, len(handlerAttrs)+record.NumAttrs())
+	attrs = append(attrs, handlerAttrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case utils.TraceIDKey, utils.SpanIDKey:
+			// передаются полями записи из контекста
+			return true
+		case utils.StacktraceKey:
+			attrs = append(attrs, log.String(string(semconv.ExceptionStacktraceKey), attr.Value.String()))
+			return true
+		case utils.ErrorKey:
+			if err, ok := attr.Value.Any().(error); ok {
+				attrs = append(attrs, log.String(string(semconv.ExceptionMessageKey), err.Error()))
+				var errStack *errors.Error
+				if errors.As(err, &errStack) {
+					attrs = append(attrs, log.String(string(semconv.ExceptionTypeKey), errStack.TypeName()))
+				}
+				return true
+			}
+		}
+		attrs = appendAttr(attrs, group, attr)
+		return true
+	})
+	rec.AddAttributes(attrs...)
+	return rec
+}
+
+func appendAttrs(result []log.KeyValue, group string, attrs []slog.Attr) []log.KeyValue {
+	for _, attr := range attrs {
+		result = appendAttr(result, group, attr)
+	}
+	return result
+}
+
+// appendAttr разворачивает группы в ключи вида "group.key", как и tracing.Attributes
+func appendAttr(result []log.KeyValue, group string, attr slog.Attr) []log.KeyValue {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return result
+	}
+	key := joinKey(group, attr.Key)
+	if attr.Value.Kind() == slog.KindGroup {
+		return appendAttrs(result, key, attr.Value.Group())
+	}
+	return append(result, log.KeyValue{Key: key, Value: convertValue(attr.Key, attr.Value)})
+}
+
+func convertValue(key string, v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return log.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindDuration:
+		return log.Int64Value(v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return log.StringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindString:
+		return log.StringValue(hide.Hide(key, v.String()))
+	}
+	switch val := v.Any().(type) {
+	case []byte:
+		return log.BytesValue(val)
+	case error:
+		return log.StringValue(val.Error())
+	case fmt.Stringer:
+		return log.StringValue(hide.Hide(key, val.String()))
+	default:
+		return log.StringValue(hide.Hide(key, fmt.Sprintf("%+v", val)))
+	}
+}
+
+func joinKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
@@ -0,0 +1,189 @@
+// Package otlp содержит slog.Handler, который отправляет записи через OTel Logs SDK по OTLP.
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/MikL9/observability/otlpconfig"
+	"github.com/MikL9/observability/resource"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+)
+
+const scopeName = "github.com/MikL9/observability/logger"
+
+type Option struct {
+	// log level (default: debug)
+	Level slog.Leveler
+
+	// адрес коллектора, host:port или URL
+	Endpoint string
+	// протокол отправки (default: grpc)
+	Protocol otlpconfig.Protocol
+	// TLS при подключении к коллектору, nil означает незащищенное соединение
+	TLS         *tls.Config
+	Headers     map[string]string
+	Compression otlpconfig.Compression
+
+	// Resource общий с tracing.Init, например observability.GetResource().
+	// Если не задан, создается resource сервиса ServiceName
+	Resource    *sdkresource.Resource
+	ServiceName string
+
+	// Exporter заменяет OTLP экспортер, Endpoint и настройки подключения при этом не используются
+	Exporter sdklog.Exporter
+
+	ExportInterval     time.Duration // default: 1s
+	ExportMaxBatchSize int           // default: 512
+	MaxQueueSize       int           // default: 2048
+}
+
+func (o Option) NewHandler(ctx context.Context) (*Handler, error) {
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+
+	exporter := o.Exporter
+	if exporter == nil {
+		var err error
+		if exporter, err = o.newExporter(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	res := o.Resource
+	if res == nil {
+		var err error
+		if res, err = resource.New(ctx, o.ServiceName); err != nil {
+			return nil, err
+		}
+	}
+
+	var batchOpts []sdklog.BatchProcessorOption
+	if o.ExportInterval > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(o.ExportInterval))
+	}
+	if o.ExportMaxBatchSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportMaxBatchSize(o.ExportMaxBatchSize))
+	}
+	if o.MaxQueueSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithMaxQueueSize(o.MaxQueueSize))
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+	)
+	return &Handler{
+		level:    o.Level,
+		provider: provider,
+		logger:   provider.Logger(scopeName),
+	}, nil
+}
+
+func (o Option) newExporter(ctx context.Context) (sdklog.Exporter, error) {
+	if o.Endpoint == "" {
+		return nil, fmt.Errorf("otlp handler: missing endpoint")
+	}
+	withURL := strings.Contains(o.Endpoint, "://")
+
+	switch o.Protocol {
+	case otlpconfig.ProtocolHTTP:
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(o.Endpoint)}
+		if withURL {
+			opts = []otlploghttp.Option{otlploghttp.WithEndpointURL(o.Endpoint)}
+		}
+		if o.TLS != nil {
+			opts = append(opts, otlploghttp.WithTLSClientConfig(o.TLS))
+		} else if !strings.HasPrefix(o.Endpoint, "https://") {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		if len(o.Headers) > 0 {
+			opts = append(opts, otlploghttp.WithHeaders(o.Headers))
+		}
+		if o.Compression == otlpconfig.CompressionGzip {
+			opts = append(opts, otlploghttp.WithCompression(otlploghttp.GzipCompression))
+		}
+		exporter, err := otlploghttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlploghttp.New: %w", err)
+		}
+		return exporter, nil
+	case otlpconfig.ProtocolGRPC, "":
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(o.Endpoint)}
+		if withURL {
+			opts = []otlploggrpc.Option{otlploggrpc.WithEndpointURL(o.Endpoint)}
+		}
+		if o.TLS != nil {
+			opts = append(opts, otlploggrpc.WithTLSCredentials(credentials.NewTLS(o.TLS)))
+		} else if !strings.HasPrefix(o.Endpoint, "https://") {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		if len(o.Headers) > 0 {
+			opts = append(opts, otlploggrpc.WithHeaders(o.Headers))
+		}
+		if o.Compression == otlpconfig.CompressionGzip {
+			opts = append(opts, otlploggrpc.WithCompressor(string(otlpconfig.CompressionGzip)))
+		}
+		exporter, err := otlploggrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlploggrpc.New: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("otlp handler: unknown protocol %q", o.Protocol)
+	}
+}
+
+// Handler передает записи в OTel Logger. Идентификаторы трейса и спана берутся
+// из контекста и устанавливаются в поля записи, а не в атрибуты.
+type Handler struct {
+	level    slog.Leveler
+	provider *sdklog.LoggerProvider
+	logger   log.Logger
+	attrs    []log.KeyValue
+	group    string
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	h.logger.Emit(ctx, convertRecord(record, h.attrs, h.group))
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = appendAttrs(append([]log.KeyValue(nil), h.attrs...), h.group, attrs)
+	return &next
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.group = joinKey(h.group, name)
+	return &next
+}
+
+// ForceFlush отправляет накопленные записи
+func (h *Handler) ForceFlush(ctx context.Context) error {
+	return h.provider.ForceFlush(ctx)
+}
+
+// Shutdown отправляет накопленные записи и останавливает экспорт
+func (h *Handler) Shutdown(ctx context.Context) error {
+	return h.provider.Shutdown(ctx)
+}
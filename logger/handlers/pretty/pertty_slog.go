@@ -5,16 +5,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/mattn/go-isatty"
+
+	"github.com/MikL9/observability/logger/errors"
 	"github.com/MikL9/observability/utils"
 )
 
 const (
 	timeFormat = "[15:04:05.000]"
+	indent     = 2
 
 	reset = "\033[0m"
 
@@ -36,135 +47,314 @@ const (
 	white        = 97
 )
 
-func colorize(colorCode int, v string) string {
-	return fmt.Sprintf("\033[%sm%s%s", strconv.Itoa(colorCode), v, reset)
-}
+// ColorMode режим раскраски вывода
+type ColorMode int
 
-func suppressDefaults(
-	next func([]string, slog.Attr) slog.Attr,
-) func([]string, slog.Attr) slog.Attr {
-	return func(groups []string, a slog.Attr) slog.Attr {
-		if a.Key == slog.TimeKey ||
-			a.Key == slog.LevelKey ||
-			a.Key == slog.MessageKey {
-			return slog.Attr{}
-		}
-		if next == nil {
-			return a
-		}
-		return next(groups, a)
-	}
-}
+const (
+	// ColorAuto раскрашивает вывод, если writer терминал и не задана переменная NO_COLOR
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
 
-type Handler struct {
-	h slog.Handler
-	b *bytes.Buffer
-	m *sync.Mutex
+type Option struct {
+	// log level (default: debug)
+	Level slog.Leveler
+	// Writer (default: os.Stdout)
+	Writer io.Writer
+	Color  ColorMode
+	// AddSource выводит file:line места вызова
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
 }
 
+// NewHandler создает обработчик с выводом в os.Stdout
 func NewHandler(opts *slog.HandlerOptions) *Handler {
 	if opts == nil {
 		opts = &slog.HandlerOptions{}
 	}
-	b := &bytes.Buffer{}
+	return Option{
+		Level:       opts.Level,
+		AddSource:   opts.AddSource,
+		ReplaceAttr: opts.ReplaceAttr,
+	}.NewHandler()
+}
+
+func (o Option) NewHandler() *Handler {
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.Writer == nil {
+		o.Writer = os.Stdout
+	}
 	return &Handler{
-		b: b,
-		h: slog.NewJSONHandler(b, &slog.HandlerOptions{
-			Level:       opts.Level,
-			AddSource:   opts.AddSource,
-			ReplaceAttr: suppressDefaults(opts.ReplaceAttr),
-		}),
-		m: &sync.Mutex{},
+		option: o,
+		color:  useColor(o.Color, o.Writer),
+		m:      &sync.Mutex{},
 	}
 }
 
-func (h *Handler) computeAttrs(
-	ctx context.Context,
-	r slog.Record,
-) (map[string]any, error) {
-	h.m.Lock()
-	defer func() {
-		h.b.Reset()
-		h.m.Unlock()
-	}()
-	if err := h.h.Handle(ctx, r); err != nil {
-		return nil, fmt.Errorf("error when calling inner handler's Handle: %w", err)
+func useColor(mode ColorMode, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
 	}
-
-	var attrs map[string]any
-	err := json.Unmarshal(h.b.Bytes(), &attrs)
-	if err != nil {
-		return nil, fmt.Errorf("error when unmarshaling inner handler's Handle result: %w", err)
+	if os.Getenv("NO_COLOR") != "" {
+		return false
 	}
-	return attrs, nil
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
 }
 
-func compileAttrs(attrs map[string]any, ident int) string {
-	msg := strings.Builder{}
-	for key, val := range attrs {
-		if key == utils.StacktraceKey {
-			continue
-		}
+// group атрибуты, добавленные через WithAttrs после WithGroup(name)
+type group struct {
+	name  string
+	attrs []slog.Attr
+}
 
-		msg.WriteString(strings.Repeat(" ", ident))
-		if inherit, ok := val.(map[string]any); ok {
-			msg.WriteString(fmt.Sprintf("%s=\n", key))
-			msg.WriteString(compileAttrs(inherit, ident*2))
-			continue
-		}
-		msg.WriteString(fmt.Sprintf("%s=%#v\n", key, val))
-	}
-	return msg.String()
+type Handler struct {
+	option Option
+	color  bool
+	// attrs атрибуты вне групп, groups открытые группы по порядку
+	attrs  []slog.Attr
+	groups []group
+	m      *sync.Mutex
 }
 
-func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+func (h *Handler) colorize(colorCode int, v string) string {
+	if !h.color {
+		return v
+	}
+	return fmt.Sprintf("\033[%sm%s%s", strconv.Itoa(colorCode), v, reset)
+}
 
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
 	level := r.Level.String() + ":"
 
 	switch r.Level {
 	case slog.LevelDebug:
-		level = colorize(darkGray, level)
+		level = h.colorize(darkGray, level)
 	case slog.LevelInfo:
-		level = colorize(cyan, level)
+		level = h.colorize(cyan, level)
 	case slog.LevelWarn:
-		level = colorize(lightYellow, level)
+		level = h.colorize(lightYellow, level)
 	case slog.LevelError:
-		level = colorize(lightRed, level)
+		level = h.colorize(lightRed, level)
 	}
 
-	attrs, err := h.computeAttrs(ctx, r)
-	if err != nil {
-		return err
+	buf := &bytes.Buffer{}
+	buf.WriteString(h.colorize(lightGray, r.Time.Format(timeFormat)))
+	buf.WriteByte(' ')
+	buf.WriteString(level)
+	buf.WriteByte(' ')
+	buf.WriteString(h.colorize(white, r.Message))
+	if h.option.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		buf.WriteByte(' ')
+		buf.WriteString(h.colorize(darkGray, shortSource(frame.File, frame.Line)))
 	}
+	buf.WriteString(" {\n")
+
+	attrs := h.recordAttrs(r)
+	var stack string
+	var errStack *errors.Error
+	attrs = slices.DeleteFunc(attrs, func(a slog.Attr) bool {
+		switch a.Key {
+		case utils.StacktraceKey:
+			stack = a.Value.String()
+			return true
+		case utils.ErrorKey:
+			if err, ok := a.Value.Any().(error); ok {
+				errors.As(err, &errStack)
+			}
+		}
+		return false
+	})
+	h.writeGroup(buf, nil, attrs, indent)
 
-	msg := strings.Builder{}
-	msg.WriteString(colorize(lightGray, r.Time.Format(timeFormat)))
-	msg.WriteRune(' ')
-	msg.WriteString(level)
-	msg.WriteRune(' ')
-	msg.WriteString(colorize(white, r.Message))
-	msg.WriteString(" {\n")
+	switch {
+	case errStack != nil:
+		h.writeStack(buf, errStack.StackFrames())
+	case stack != "":
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(h.colorize(red, utils.StacktraceKey))
+		buf.WriteString("=\n")
+		writeBlock(buf, stack, indent*2)
+	}
+	buf.WriteString("}\n")
 
-	msg.WriteString(compileAttrs(attrs, 2))
+	h.m.Lock()
+	defer h.m.Unlock()
+	_, err := h.option.Writer.Write(buf.Bytes())
+	return err
+}
 
-	if val, ok := attrs[utils.StacktraceKey]; ok {
-		msg.WriteString(fmt.Sprintf("    %s=\n%s", colorize(red, utils.StacktraceKey), val))
+// recordAttrs собирает атрибуты WithAttrs и записи в порядке добавления, вкладывая их в открытые группы
+func (h *Handler) recordAttrs(r slog.Record) []slog.Attr {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		nested := append(slices.Clone(g.attrs), attrs...)
+		attrs = []slog.Attr{{Key: g.name, Value: slog.GroupValue(nested...)}}
 	}
-	msg.WriteString("}\n")
+	return append(slices.Clone(h.attrs), attrs...)
+}
 
-	fmt.Print(msg.String())
+func (h *Handler) writeGroup(buf *bytes.Buffer, groups []string, attrs []slog.Attr, ident int) {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if h.option.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+			a = h.option.ReplaceAttr(groups, a)
+			a.Value = a.Value.Resolve()
+		}
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+
+		pad := strings.Repeat(" ", ident)
+		if a.Value.Kind() == slog.KindGroup {
+			group := a.Value.Group()
+			if len(group) == 0 {
+				continue
+			}
+			if a.Key == "" {
+				h.writeGroup(buf, groups, group, ident)
+				continue
+			}
+			buf.WriteString(pad + a.Key + "=\n")
+			h.writeGroup(buf, append(slices.Clone(groups), a.Key), group, ident+indent)
+			continue
+		}
+
+		buf.WriteString(pad + a.Key + "=")
+		h.writeValue(buf, a.Value, ident)
+	}
+}
+
+func (h *Handler) writeValue(buf *bytes.Buffer, v slog.Value, ident int) {
+	switch v.Kind() {
+	case slog.KindString:
+		s := v.String()
+		if pretty, ok := indentJSON(s); ok {
+			s = pretty
+		}
+		if strings.Contains(s, "\n") {
+			buf.WriteByte('\n')
+			writeBlock(buf, s, ident+indent)
+			return
+		}
+		buf.WriteString(strconv.Quote(s))
+	case slog.KindTime:
+		buf.WriteString(v.Time().Format(time.RFC3339Nano))
+	case slog.KindAny:
+		switch val := v.Any().(type) {
+		case error:
+			buf.WriteString(strconv.Quote(val.Error()))
+		case map[string]any:
+			buf.WriteByte('\n')
+			h.writeGroup(buf, nil, mapAttrs(val), ident+indent)
+			return
+		case []any, []byte:
+			b, err := json.Marshal(val)
+			if err != nil {
+				fmt.Fprintf(buf, "%+v", val)
+				break
+			}
+			buf.Write(b)
+		default:
+			fmt.Fprintf(buf, "%+v", val)
+		}
+	default:
+		buf.WriteString(v.String())
+	}
+	buf.WriteByte('\n')
+}
+
+func (h *Handler) writeStack(buf *bytes.Buffer, frames []errors.StackFrame) {
+	pad := strings.Repeat(" ", indent*2)
+	buf.WriteString(strings.Repeat(" ", indent))
+	buf.WriteString(h.colorize(red, utils.StacktraceKey))
+	buf.WriteString("=\n")
+	for _, frame := range frames {
+		name := frame.Name
+		if frame.Package != "" {
+			name = frame.Package + "." + name
+		}
+		buf.WriteString(pad + h.colorize(white, name) + "\n")
+		buf.WriteString(pad + strings.Repeat(" ", indent) + h.colorize(darkGray, shortSource(frame.File, frame.LineNumber)) + "\n")
+	}
+}
+
+// mapAttrs атрибуты из map, отсортированные по ключу, так как порядок map не определен
+func mapAttrs(m map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, m[key]))
+	}
+	return attrs
+}
+
+// indentJSON форматирует строку, если она содержит JSON объект или массив
+func indentJSON(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return "", false
+	}
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, []byte(trimmed), "", strings.Repeat(" ", indent)); err != nil {
+		return "", false
+	}
+	return out.String(), true
+}
+
+func writeBlock(buf *bytes.Buffer, s string, ident int) {
+	pad := strings.Repeat(" ", ident)
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		buf.WriteString(pad + line + "\n")
+	}
+}
 
-	return nil
+// shortSource file:line с последней директорией пути
+func shortSource(file string, line int) string {
+	dir, name := filepath.Split(file)
+	return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(dir), name), line)
 }
 
-func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.h.Enabled(ctx, level)
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.option.Level.Level()
 }
 
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{h: h.h.WithAttrs(attrs), b: h.b, m: h.m}
+	if len(attrs) == 0 {
+		return h
+	}
+	next := *h
+	if len(h.groups) == 0 {
+		next.attrs = append(slices.Clone(h.attrs), attrs...)
+		return &next
+	}
+	next.groups = slices.Clone(h.groups)
+	last := &next.groups[len(next.groups)-1]
+	last.attrs = append(slices.Clone(last.attrs), attrs...)
+	return &next
 }
 
 func (h *Handler) WithGroup(name string) slog.Handler {
-	return &Handler{h: h.h.WithGroup(name), b: h.b, m: h.m}
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.groups = append(slices.Clone(h.groups), group{name: name})
+	return &next
 }
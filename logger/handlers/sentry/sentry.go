@@ -7,9 +7,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/MikL9/observability/metrics"
 	"github.com/getsentry/sentry-go"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type Handler struct {
@@ -53,36 +52,39 @@ func (h *Handler) WithGroup(_ string) slog.Handler {
 type Transport struct {
 	rt http.RoundTripper
 
-	RequestsCounter         *prometheus.CounterVec
-	RequestsErrorCounter    *prometheus.CounterVec
-	RequestsDuration        *prometheus.HistogramVec
-	ActiveConnectionCounter prometheus.Gauge
+	RequestsCounter         metrics.Counter
+	RequestsErrorCounter    metrics.Counter
+	RequestsDuration        metrics.Histogram
+	ActiveConnectionCounter metrics.UpDownCounter
 }
 
+// NewTransport создает метрики через metrics.Default, поэтому провайдер метрик
+// должен быть задан до создания обработчика
 func NewTransport(rt http.RoundTripper) *Transport {
 	serviceID := "observability_sentry_handler"
+	provider := metrics.Default()
 	return &Transport{
 		rt: rt,
-		RequestsCounter: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(prometheus.CounterOpts{
+		RequestsCounter: provider.Counter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_total",
 			Help:      "Общее количество запросов",
-		}, []string{"status"}),
-		RequestsErrorCounter: promauto.With(prometheus.DefaultRegisterer).NewCounterVec(prometheus.CounterOpts{
+			Labels:    []string{"status"},
+		}),
+		RequestsErrorCounter: provider.Counter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_total_error",
 			Help:      "Общее количество ошибочных запросов",
-		}, []string{}),
-		RequestsDuration: promauto.With(prometheus.DefaultRegisterer).NewHistogramVec(prometheus.HistogramOpts{
+		}),
+		RequestsDuration: provider.Histogram(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "requests_duration",
 			Help:      "Продолжительность запросов",
-			Buckets:   prometheus.DefBuckets,
-		}, []string{}),
-		ActiveConnectionCounter: promauto.With(prometheus.DefaultRegisterer).NewGauge(prometheus.GaugeOpts{
+		}),
+		ActiveConnectionCounter: provider.UpDownCounter(metrics.Opts{
 			Namespace: "http",
 			Subsystem: serviceID,
 			Name:      "active_connection_total",
@@ -93,20 +95,21 @@ func NewTransport(rt http.RoundTripper) *Transport {
 
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	var (
+		ctx        = req.Context()
 		timeStart  = time.Now()
 		statusCode string
 	)
-	t.ActiveConnectionCounter.Inc()
+	t.ActiveConnectionCounter.Add(ctx, 1)
 
 	resp, err := t.rt.RoundTrip(req)
 	if resp != nil {
 		statusCode = strconv.Itoa(resp.StatusCode)
 	}
-	t.RequestsCounter.WithLabelValues(statusCode).Inc()
-	t.ActiveConnectionCounter.Dec()
-	t.RequestsDuration.WithLabelValues().Observe(time.Since(timeStart).Seconds())
+	t.RequestsCounter.Add(ctx, 1, statusCode)
+	t.ActiveConnectionCounter.Add(ctx, -1)
+	t.RequestsDuration.Observe(ctx, time.Since(timeStart).Seconds())
 	if err != nil {
-		t.RequestsErrorCounter.WithLabelValues().Inc()
+		t.RequestsErrorCounter.Add(ctx, 1)
 	}
 	return resp, err
 }
@@ -0,0 +1,99 @@
+// Package span содержит slog.Handler, который дублирует записи лога в активный спан.
+package span
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/tracing"
+	"github.com/MikL9/observability/utils"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const severityKey = "log.severity"
+
+// Handler добавляет записи лога событиями в спан из контекста. Записи с ошибкой
+// сохраняются через RecordError вместе со стектрейсом errors.Error.
+// Записи вне спана или в спане, который не записывается, пропускаются.
+type Handler struct {
+	level slog.Leveler
+	attrs []slog.Attr
+	group string
+}
+
+func NewHandler(level slog.Leveler) *Handler {
+	return &Handler{level: level}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *Handler) Handle(ctx context.Context, rec slog.Record) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	var err error
+	attrs := make([]slog.Attr, 0, rec.NumAttrs())
+	rec.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case utils.ErrorKey:
+			if e, ok := attr.Value.Any().(error); ok {
+				err = e
+				return true
+			}
+		case utils.StacktraceKey, utils.TraceIDKey, utils.SpanIDKey:
+			return true
+		}
+		attrs = append(attrs, attr)
+		return true
+	})
+	if h.group != "" {
+		attrs = []slog.Attr{{Key: h.group, Value: slog.GroupValue(attrs...)}}
+	}
+
+	eventAttrs := append([]attribute.KeyValue{attribute.String(severityKey, rec.Level.String())},
+		tracing.Attributes(append(slices.Clone(h.attrs), attrs...)...)...)
+	options := []trace.EventOption{trace.WithTimestamp(rec.Time)}
+
+	if err == nil {
+		span.AddEvent(rec.Message, append(options, trace.WithAttributes(eventAttrs...))...)
+		return nil
+	}
+
+	var errStack *errors.Error
+	if errors.As(err, &errStack) {
+		eventAttrs = append(eventAttrs, semconv.ExceptionStacktrace(errStack.ErrorStack()))
+	} else {
+		options = append(options, trace.WithStackTrace(true))
+	}
+	span.RecordError(err, append(options, trace.WithAttributes(eventAttrs...))...)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	if h.group != "" {
+		attrs = []slog.Attr{{Key: h.group, Value: slog.GroupValue(attrs...)}}
+	}
+	next.attrs = append(slices.Clone(h.attrs), attrs...)
+	return &next
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	next.group = name
+	return &next
+}
@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultSink имя уровня обработчика из WithDefaultHandler
+const DefaultSink = "default"
+
+// levels реестр уровней обработчиков и пакетов, изменяемых во время работы
+var levels = &levelRegistry{sinks: make(map[string]*slog.LevelVar)}
+
+type packageLevel struct {
+	pattern string
+	level   slog.Level
+}
+
+type levelRegistry struct {
+	mu    sync.RWMutex
+	sinks map[string]*slog.LevelVar
+	// packages отсортированы по убыванию длины шаблона, первое совпадение самое точное
+	packages []packageLevel
+	// funcs кеш pc -> путь пакета
+	funcs sync.Map
+}
+
+// RegisterLevel регистрирует уровень обработчика name и возвращает его.
+// Если level уже *slog.LevelVar, регистрируется он сам, заменяя прежний уровень с тем же именем.
+// Иначе повторная регистрация имени возвращает существующий уровень с его текущим значением,
+// например установленным через SetSinkLevel до повторной инициализации логгера,
+// а новый создается со значением level (по умолчанию Debug)
+func RegisterLevel(name string, level slog.Leveler) *slog.LevelVar {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	if v, ok := level.(*slog.LevelVar); ok {
+		levels.sinks[name] = v
+		return v
+	}
+	if existing, ok := levels.sinks[name]; ok {
+		return existing
+	}
+	v := &slog.LevelVar{}
+	v.Set(levelOf(level))
+	levels.sinks[name] = v
+	return v
+}
+
+// UnregisterLevel удаляет уровень обработчика name из реестра
+func UnregisterLevel(name string) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	delete(levels.sinks, name)
+}
+
+// SnapshotLevels сохраняет уровни обработчиков, пакетов и SetLevel и возвращает функцию,
+// восстанавливающую их. Используется в тестах и observability.Snapshot
+func SnapshotLevels() (restore func()) {
+	levels.mu.RLock()
+	sinks := make(map[string]*slog.LevelVar, len(levels.sinks))
+	values := make(map[string]slog.Level, len(levels.sinks))
+	for name, v := range levels.sinks {
+		sinks[name] = v
+		values[name] = v.Level()
+	}
+	packages := slices.Clone(levels.packages)
+	levels.mu.RUnlock()
+	prevLevel, prevLevelSet := level.Level(), levelSet.Load()
+
+	return func() {
+		levels.mu.Lock()
+		defer levels.mu.Unlock()
+		for name, v := range sinks {
+			v.Set(values[name])
+		}
+		levels.sinks = sinks
+		levels.packages = packages
+		level.Set(prevLevel)
+		levelSet.Store(prevLevelSet)
+	}
+}
+
+// SetSinkLevel меняет уровень зарегистрированного обработчика name
+func SetSinkLevel(name string, l slog.Level) error {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	v, ok := levels.sinks[name]
+	if !ok {
+		return fmt.Errorf("unknown log sink %q", name)
+	}
+	v.Set(l)
+	return nil
+}
+
+// SinkLevels возвращает текущие уровни зарегистрированных обработчиков
+func SinkLevels() map[string]slog.Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	res := make(map[string]slog.Level, len(levels.sinks))
+	for name, v := range levels.sinks {
+		res[name] = v.Level()
+	}
+	return res
+}
+
+// SetPackageLevel задает уровень для записей из пакетов, подходящих под pattern.
+// Шаблон "payments" соответствует пакету с таким последним сегментом пути или полным путем,
+// "payments/..." также и всем вложенным пакетам. Уровень пакета заменяет SetLevel для записей
+// из этих пакетов, уровни обработчиков продолжают действовать. При нескольких совпадениях
+// выбирается самый длинный шаблон
+func SetPackageLevel(pattern string, l slog.Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	for i := range levels.packages {
+		if levels.packages[i].pattern == pattern {
+			levels.packages[i].level = l
+			return
+		}
+	}
+	levels.packages = append(levels.packages, packageLevel{pattern: pattern, level: l})
+	sort.SliceStable(levels.packages, func(i, j int) bool {
+		return len(levels.packages[i].pattern) > len(levels.packages[j].pattern)
+	})
+}
+
+// ResetPackageLevel удаляет уровень, заданный через SetPackageLevel
+func ResetPackageLevel(pattern string) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	for i := range levels.packages {
+		if levels.packages[i].pattern == pattern {
+			levels.packages = append(levels.packages[:i], levels.packages[i+1:]...)
+			return
+		}
+	}
+}
+
+// PackageLevels возвращает уровни, заданные через SetPackageLevel
+func PackageLevels() map[string]slog.Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	res := make(map[string]slog.Level, len(levels.packages))
+	for _, p := range levels.packages {
+		res[p.pattern] = p.level
+	}
+	return res
+}
+
+// packageLevel возвращает уровень пакета функции pc, если он задан
+func (r *levelRegistry) packageLevel(pc uintptr) (slog.Level, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.packages) == 0 || pc == 0 {
+		return 0, false
+	}
+	pkg := r.packagePath(pc)
+	for _, p := range r.packages {
+		if matchPackage(p.pattern, pkg) {
+			return p.level, true
+		}
+	}
+	return 0, false
+}
+
+func (r *levelRegistry) packagePath(pc uintptr) string {
+	if pkg, ok := r.funcs.Load(pc); ok {
+		return pkg.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg := funcPackage(frame.Function)
+	r.funcs.Store(pc, pkg)
+	return pkg
+}
+
+// funcPackage выделяет путь пакета из полного имени функции вида "github.com/a/b.(*T).Method"
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/") + 1
+	if dot := strings.Index(fn[slash:], "."); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
+
+func matchPackage(pattern, pkg string) bool {
+	base, recursive := strings.CutSuffix(pattern, "/...")
+	if pkg == base || strings.HasSuffix(pkg, "/"+base) {
+		return true
+	}
+	return recursive && (strings.HasPrefix(pkg, base+"/") || strings.Contains(pkg, "/"+base+"/"))
+}
+
+// enabled проверяет уровень записи из pc по уровню пакета, если он задан, иначе по уровню def.
+// Применяется только в Log, уровни обработчиков продолжают действовать
+func enabled(pc uintptr, lvl slog.Level, def slog.Leveler) bool {
+	if l, ok := levels.packageLevel(pc); ok {
+		return lvl >= l
+	}
+	return lvl >= def.Level()
+}
+
+// levelHandler фильтрует записи по уровню из реестра перед передачей в next
+type levelHandler struct {
+	level *slog.LevelVar
+	next  slog.Handler
+}
+
+// WithNamedLevel регистрирует уровень name (см. RegisterLevel) и фильтрует по нему записи next,
+// собственный уровень next продолжает действовать. Уровень меняется во время работы через SetSinkLevel.
+// Позволяет задать отдельные уровни нескольким обработчикам одного типа, например двум топикам Kafka
+func WithNamedLevel(name string, level slog.Leveler, next slog.Handler) slog.Handler {
+	return &levelHandler{level: RegisterLevel(name, level), next: next}
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return lvl >= h.level.Level() && h.next.Enabled(ctx, lvl)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if !h.Enabled(ctx, rec.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, rec)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+// Unwrap возвращает исходный обработчик
+func (h *levelHandler) Unwrap() slog.Handler {
+	return h.next
+}
+
+func levelOf(l slog.Leveler) slog.Level {
+	if l == nil {
+		return slog.LevelDebug
+	}
+	return l.Level()
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedLevel(t *testing.T) {
+	defer SnapshotLevels()()
+	buf := new(bytes.Buffer)
+	require.NoError(t, SetupLogger(WithNamedLevel("test", slog.LevelInfo, slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))))
+	ctx := context.Background()
+
+	logged := func(msg string) bool {
+		defer buf.Reset()
+		return strings.Contains(buf.String(), `"msg":"`+msg+`"`)
+	}
+
+	Debug(ctx, "debug sink")
+	assert.False(t, logged("debug sink"))
+
+	// уровень пакета заменяет общий уровень, но не уровень обработчика
+	SetLevel(slog.LevelWarn)
+	Info(ctx, "info global")
+	assert.False(t, logged("info global"))
+	SetPackageLevel("observability/...", slog.LevelDebug)
+	assert.Equal(t, slog.LevelDebug, PackageLevels()["observability/..."])
+	Info(ctx, "info package")
+	assert.True(t, logged("info package"))
+	Debug(ctx, "debug package")
+	assert.False(t, logged("debug package"))
+
+	// более длинный шаблон точнее
+	SetPackageLevel("github.com/MikL9/observability/logger", slog.LevelError)
+	Info(ctx, "info exact")
+	assert.False(t, logged("info exact"))
+	ResetPackageLevel("github.com/MikL9/observability/logger")
+	ResetPackageLevel("observability/...")
+	assert.Empty(t, PackageLevels())
+	SetLevel(slog.LevelDebug)
+
+	require.NoError(t, SetSinkLevel("test", slog.LevelError))
+	assert.Equal(t, slog.LevelError, SinkLevels()["test"])
+	Warn(ctx, "warn sink")
+	assert.False(t, logged("warn sink"))
+	assert.Error(t, SetSinkLevel("unknown", slog.LevelInfo))
+}
+
+func TestPackageDebugInProd(t *testing.T) {
+	defer SnapshotLevels()()
+	levelSet.Store(false)
+	UnregisterLevel(DefaultSink)
+	WithDefaultHandler(envProd)
+	assert.Equal(t, slog.LevelInfo, GetLevel())
+	assert.Equal(t, slog.LevelDebug, SinkLevels()[DefaultSink])
+
+	// тот же уровень DefaultSink, но с выводом в буфер
+	buf := new(bytes.Buffer)
+	require.NoError(t, SetupLogger(WithNamedLevel(DefaultSink, nil, slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))))
+	ctx := context.Background()
+
+	Debug(ctx, "debug global")
+	assert.NotContains(t, buf.String(), "debug global")
+
+	// одного SetPackageLevel достаточно, чтобы включить Debug для пакета
+	SetPackageLevel("observability/logger", slog.LevelDebug)
+	Debug(ctx, "debug package")
+	assert.Contains(t, buf.String(), "debug package")
+}
+
+func TestRegisterLevel(t *testing.T) {
+	defer SnapshotLevels()()
+
+	// повторная регистрация возвращает существующий уровень с текущим значением
+	first := RegisterLevel("register", slog.LevelInfo)
+	require.NoError(t, SetSinkLevel("register", slog.LevelDebug))
+	second := RegisterLevel("register", slog.LevelError)
+	assert.Same(t, first, second)
+	assert.Equal(t, slog.LevelDebug, second.Level())
+	UnregisterLevel("register")
+	assert.NotContains(t, SinkLevels(), "register")
+
+	// переданный *slog.LevelVar регистрируется сам и меняется вызывающим кодом
+	callerLevel := &slog.LevelVar{}
+	callerLevel.Set(slog.LevelInfo)
+	h := WithNamedLevel("caller", callerLevel, slog.NewJSONHandler(new(bytes.Buffer), nil))
+	ctx := context.Background()
+	assert.True(t, h.Enabled(ctx, slog.LevelWarn))
+	callerLevel.Set(slog.LevelError)
+	assert.False(t, h.Enabled(ctx, slog.LevelWarn))
+	assert.Equal(t, slog.LevelError, SinkLevels()["caller"])
+
+	// собственный уровень обработчика продолжает действовать
+	h = WithNamedLevel("inner", slog.LevelDebug, slog.NewJSONHandler(new(bytes.Buffer), &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	}))
+	assert.False(t, h.Enabled(ctx, slog.LevelInfo))
+	assert.True(t, h.Enabled(ctx, slog.LevelWarn))
+}
+
+func TestSnapshotLevels(t *testing.T) {
+	restore := SnapshotLevels()
+	RegisterLevel("snapshot", slog.LevelInfo)
+	SetLevel(slog.LevelError)
+	SetPackageLevel("payments", slog.LevelDebug)
+	restore()
+
+	assert.NotContains(t, SinkLevels(), "snapshot")
+	assert.NotContains(t, PackageLevels(), "payments")
+	assert.NotEqual(t, slog.LevelError, GetLevel())
+}
+
+func TestMatchPackage(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		pkg     string
+		match   bool
+	}{
+		{"payments", "github.com/acme/app/payments", true},
+		{"payments", "github.com/acme/app/payments/card", false},
+		{"payments/...", "github.com/acme/app/payments/card", true},
+		{"payments/...", "github.com/acme/app/payments", true},
+		{"payments/...", "github.com/acme/app/mypayments", false},
+		{"app/payments", "github.com/acme/app/payments", true},
+		{"github.com/acme/app/...", "github.com/acme/app/orders", true},
+		{"github.com/acme/app/...", "github.com/acme/application", false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, matchPackage(tc.pattern, tc.pkg), "%s %s", tc.pattern, tc.pkg)
+	}
+	assert.Equal(t, "github.com/acme/app/payments", funcPackage("github.com/acme/app/payments.(*Service).Pay"))
+	assert.Equal(t, "main", funcPackage("main.main.func1"))
+}
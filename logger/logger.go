@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/MikL9/observability/hide"
@@ -16,6 +17,36 @@ import (
 
 var timeNow = time.Now
 
+// level минимальный уровень записей, изменяемый во время работы через SetLevel
+var level = func() *slog.LevelVar {
+	v := &slog.LevelVar{}
+	v.Set(slog.LevelDebug)
+	return v
+}()
+
+// levelSet показывает, что уровень задан через SetLevel и не заменяется уровнем окружения
+var levelSet atomic.Bool
+
+// SetLevel устанавливает минимальный уровень записей для всех обработчиков.
+// Обработчики продолжают применять и собственные уровни, уровни пакетов из SetPackageLevel
+// имеют приоритет над ним
+func SetLevel(l slog.Level) {
+	level.Set(l)
+	levelSet.Store(true)
+}
+
+// setDefaultLevel устанавливает уровень окружения, если он не задан через SetLevel
+func setDefaultLevel(l slog.Level) {
+	if !levelSet.Load() {
+		level.Set(l)
+	}
+}
+
+// GetLevel возвращает минимальный уровень записей, установленный через SetLevel
+func GetLevel() slog.Level {
+	return level.Level()
+}
+
 func SetupLogger(h slog.Handler) error {
 	slog.SetDefault(
 		slog.New(
@@ -25,15 +56,21 @@ func SetupLogger(h slog.Handler) error {
 	return nil
 }
 
-func Log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+func Log(ctx context.Context, lvl slog.Level, msg string, attrs ...slog.Attr) {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	if !enabled(pcs[0], lvl, level) {
+		return
+	}
 	logger := slog.Default()
-	if !logger.Enabled(ctx, level) {
+	if !logger.Enabled(ctx, lvl) {
+		return
+	}
+	if s := sampler.Load(); s != nil && !s.Sample(ctx, lvl, msg) {
 		return
 	}
-	var pcs [1]uintptr
-	runtime.Callers(3, pcs[:])
 
-	r := slog.NewRecord(timeNow(), level, msg, pcs[0])
+	r := slog.NewRecord(timeNow(), lvl, msg, pcs[0])
 
 	attrs = utils.SetTraceSpanIDToAttrs(ctx, attrs)
 	attrs = setUserIDToAttrs(ctx, attrs)
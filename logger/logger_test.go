@@ -1,9 +1,12 @@
 package logger
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,10 +16,21 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/MikL9/observability/logger/errors"
 	kafkaHandler "github.com/MikL9/observability/logger/handlers/kafka"
+	ecsHandler "github.com/MikL9/observability/logger/handlers/ecs"
+	gelfHandler "github.com/MikL9/observability/logger/handlers/gelf"
+	logfmtHandler "github.com/MikL9/observability/logger/handlers/logfmt"
+	otlpHandler "github.com/MikL9/observability/logger/handlers/otlp"
+	"github.com/MikL9/observability/logger/handlers/pretty"
 	"github.com/MikL9/observability/logger/handlers/sentry"
 	storage2 "github.com/MikL9/observability/logger/handlers/storage"
 	"github.com/MikL9/observability/storage"
 	"github.com/MikL9/observability/utils"
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
 func keyUserID(v string) slog.Attr        { return slog.String(string(utils.UserIDKey), v) }
@@ -349,3 +363,222 @@ func TestKafkaLogging(t *testing.T) {
 		},
 	)
 }
+
+func TestSpanHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	err := SetupLogger(WithSpanHandler(slog.LevelInfo))
+	require.NoError(t, err)
+
+	Info(context.Background(), "outside span")
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	Debug(ctx, "debug msg")
+	Info(ctx, "info msg", keyID(1), slog.Group("req", keyStatus("ok")))
+	expectedErr := errors.New(ctx, "error msg", keyIdentificationID(5432))
+	Error(ctx, expectedErr)
+	span.End()
+
+	require.Len(t, recorder.Ended(), 1)
+	events := recorder.Ended()[0].Events()
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "info msg", events[0].Name)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("log.severity", "INFO"),
+		attribute.Int64("id", 1),
+		attribute.String("req.status", "ok"),
+	}, events[0].Attributes)
+
+	assert.Equal(t, semconv.ExceptionEventName, events[1].Name)
+	assert.Contains(t, events[1].Attributes, semconv.ExceptionMessage("error msg"))
+	assert.Contains(t, events[1].Attributes, semconv.ExceptionStacktrace(expectedErr.(*errors.Error).ErrorStack()))
+	assert.Contains(t, events[1].Attributes, attribute.Int64("identification_id", 5432))
+}
+
+type MockLogExporter struct {
+	records []sdklog.Record
+}
+
+func (m *MockLogExporter) Export(_ context.Context, records []sdklog.Record) error {
+	for _, r := range records {
+		m.records = append(m.records, r.Clone())
+	}
+	return nil
+}
+
+func (m *MockLogExporter) Shutdown(context.Context) error   { return nil }
+func (m *MockLogExporter) ForceFlush(context.Context) error { return nil }
+
+func TestOTLPHandler(t *testing.T) {
+	testStaticTime(t)
+	exporter := &MockLogExporter{}
+	handler, err := otlpHandler.Option{
+		Level:       slog.LevelInfo,
+		Exporter:    exporter,
+		ServiceName: "test",
+	}.NewHandler(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, SetupLogger(handler.WithAttrs([]slog.Attr{slog.String("app", "api")})))
+
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	Debug(ctx, "debug msg")
+	Info(ctx, "info msg", keyID(1), slog.Group("req", keyStatus("ok")))
+	expectedErr := errors.New(ctx, "error msg")
+	Error(ctx, expectedErr)
+	require.NoError(t, handler.Shutdown(context.Background()))
+
+	require.Len(t, exporter.records, 2)
+	info := exporter.records[0]
+	assert.Equal(t, otellog.SeverityInfo, info.Severity())
+	assert.Equal(t, "info msg", info.Body().AsString())
+	assert.Equal(t, span.SpanContext().TraceID(), info.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), info.SpanID())
+	assert.Equal(t, staticTime, info.Timestamp().Format(time.RFC3339))
+
+	attrs := map[string]otellog.Value{}
+	info.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, "api", attrs["app"].AsString())
+	assert.Equal(t, int64(1), attrs["id"].AsInt64())
+	assert.Equal(t, "ok", attrs["req.status"].AsString())
+	assert.NotContains(t, attrs, utils.TraceIDKey)
+
+	failure := exporter.records[1]
+	assert.Equal(t, otellog.SeverityError, failure.Severity())
+	attrs = map[string]otellog.Value{}
+	failure.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, "error msg", attrs["exception.message"].AsString())
+	assert.Equal(t, expectedErr.(*errors.Error).ErrorStack(), attrs["exception.stacktrace"].AsString())
+	res := failure.Resource()
+	serviceName, _ := res.Set().Value(semconv.ServiceNameKey)
+	assert.Equal(t, "test", serviceName.AsString())
+}
+
+func TestPrettyHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := SetupLogger(pretty.Option{Writer: buf, Color: pretty.ColorNever, AddSource: true}.NewHandler())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log := slog.Default().With(keyStatus("ok")).WithGroup("req")
+	log.InfoContext(ctx, "grouped", keyID(1), slog.String("body_text", `{"b":1,"a":[1,2]}`))
+
+	lines := strings.Split(buf.String(), "\n")
+	require.Len(t, lines, 14)
+	assert.Regexp(t, `^\[\d\d:\d\d:\d\d.\d{3}\] INFO: grouped logger/logger_test.go:\d+ \{$`, lines[0])
+	assert.Equal(t, []string{
+		`  status="ok"`,
+		`  req=`,
+		`    id=1`,
+		`    body_text=`,
+		`      {`,
+		`        "b": 1,`,
+		`        "a": [`,
+		`          1,`,
+		`          2`,
+		`        ]`,
+		`      }`,
+		`}`,
+	}, lines[1:13])
+	buf.Reset()
+
+	expectedErr := errors.New(ctx, "pretty error")
+	Error(ctx, expectedErr)
+	out := buf.String()
+	assert.Contains(t, out, `ERROR: pretty error`)
+	assert.Contains(t, out, `  error_msg="pretty error"`)
+	assert.Contains(t, out, "  stacktrace=\n    github.com/MikL9/observability/logger.TestPrettyHandler\n      logger/logger_test.go:")
+	buf.Reset()
+
+	// стек берется и из ошибки, обернутой через %w
+	wrapped := fmt.Errorf("outer: %w", errors.New(ctx, "inner"))
+	slog.Default().ErrorContext(ctx, "wrapped", slog.Any(utils.ErrorKey, wrapped))
+	out = buf.String()
+	assert.Contains(t, out, `  error_msg="outer: inner"`)
+	assert.Contains(t, out, "  stacktrace=\n    github.com/MikL9/observability/logger.TestPrettyHandler\n      logger/logger_test.go:")
+}
+
+func TestFormatHandlers(t *testing.T) {
+	testStaticTime(t)
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+	ctx = context.WithValue(ctx, utils.UserIDKey, "42")
+	expectedErr := errors.New(ctx, "format error")
+	traceID := span.SpanContext().TraceID().String()
+
+	t.Run("logfmt", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, SetupLogger(WithLogfmtHandler(logfmtHandler.Option{Writer: buf})))
+		Error(ctx, expectedErr, slog.Group("req", keyID(1)))
+
+		out := buf.String()
+		assert.True(t, strings.HasPrefix(out, `time=1999-12-31T23:59:57.000Z level=error msg="format error"`), out)
+		assert.Contains(t, out, `error="format error"`)
+		assert.Contains(t, out, `req.id=1`)
+		assert.Contains(t, out, `trace_id=`+traceID)
+		assert.Contains(t, out, `user_id=42`)
+		assert.Equal(t, 1, strings.Count(out, "\n"))
+	})
+
+	t.Run("ecs", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, SetupLogger(WithECSHandler(ecsHandler.Option{Writer: buf, ServiceName: "svc", AddSource: true})))
+		Error(ctx, expectedErr)
+
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &msg))
+		assert.Equal(t, staticTime, msg["@timestamp"])
+		assert.Equal(t, "error", msg["log.level"])
+		assert.Equal(t, "format error", msg["message"])
+		assert.Equal(t, "format error", msg["error.message"])
+		assert.Equal(t, expectedErr.(*errors.Error).ErrorStack(), msg["error.stack_trace"])
+		assert.Equal(t, traceID, msg["trace.id"])
+		assert.Equal(t, span.SpanContext().SpanID().String(), msg["span.id"])
+		assert.Equal(t, "42", msg["user.id"])
+		assert.Equal(t, ecsHandler.Version, msg["ecs.version"])
+		assert.Equal(t, "svc", msg["service.name"])
+		assert.Contains(t, msg["log.origin"], "file")
+	})
+
+	t.Run("gelf", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, SetupLogger(WithGELFHandler(gelfHandler.Option{Writer: buf, Host: "host", NullDelimiter: true})))
+		Error(ctx, expectedErr, slog.Group("req", keyStatus("ok"), slog.Bool("retry", true)))
+
+		require.Equal(t, byte(0), buf.Bytes()[buf.Len()-1])
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes()[:buf.Len()-1], &msg))
+		assert.Equal(t, gelfHandler.Version, msg["version"])
+		assert.Equal(t, "host", msg["host"])
+		assert.Equal(t, "format error", msg["short_message"])
+		assert.Equal(t, expectedErr.(*errors.Error).ErrorStack(), msg["full_message"])
+		assert.Equal(t, float64(3), msg["level"])
+		assert.Equal(t, float64(946684797), msg["timestamp"])
+		assert.Equal(t, "format error", msg["_error"])
+		assert.Equal(t, traceID, msg["_trace_id"])
+		assert.Equal(t, "42", msg["_user_id"])
+		assert.Equal(t, "ok", msg["_req_status"])
+		assert.Equal(t, "true", msg["_req_retry"])
+	})
+
+	t.Run("gelf groups", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		handler := gelfHandler.Option{Writer: buf}.NewHandler()
+		slog.New(handler).With(keyID(7)).WithGroup("req").Info("grouped", keyID(8))
+
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &msg))
+		assert.Equal(t, float64(7), msg["__id"])
+		assert.Equal(t, float64(8), msg["_req_id"])
+	})
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
@@ -8,8 +9,13 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	kafkaHandler "github.com/MikL9/observability/logger/handlers/kafka"
+	ecsHandler "github.com/MikL9/observability/logger/handlers/ecs"
+	gelfHandler "github.com/MikL9/observability/logger/handlers/gelf"
+	logfmtHandler "github.com/MikL9/observability/logger/handlers/logfmt"
+	otlpHandler "github.com/MikL9/observability/logger/handlers/otlp"
 	"github.com/MikL9/observability/logger/handlers/pretty"
 	sentryHandler "github.com/MikL9/observability/logger/handlers/sentry"
+	spanHandler "github.com/MikL9/observability/logger/handlers/span"
 )
 
 const (
@@ -23,29 +29,46 @@ type Option struct {
 	Handlers []slog.Handler
 }
 
+// WithDefaultHandler выбирает обработчик по окружению. Уровень регистрируется под именем
+// DefaultSink со значением Debug и меняется во время работы через SetSinkLevel, а уровень окружения
+// (Info для prod) устанавливается как общий уровень, если он не задан через SetLevel.
+// Поэтому SetPackageLevel может включить Debug для отдельных пакетов и в prod
 func WithDefaultHandler(env string) slog.Handler {
+	var next func(level slog.Leveler) slog.Handler
 	switch env {
 	case envLocal:
-		return pretty.NewHandler(&slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		})
+		setDefaultLevel(slog.LevelDebug)
+		next = func(level slog.Leveler) slog.Handler {
+			return pretty.NewHandler(&slog.HandlerOptions{Level: level})
+		}
 	case envDev, envStage:
-		return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		})
+		setDefaultLevel(slog.LevelDebug)
+		next = func(level slog.Leveler) slog.Handler {
+			return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+		}
 	case envProd:
-		return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		})
+		setDefaultLevel(slog.LevelInfo)
+		next = func(level slog.Leveler) slog.Handler {
+			return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+		}
 	default:
 		panic("Unknown env " + env)
 	}
+	level := RegisterLevel(DefaultSink, slog.LevelDebug)
+	return &levelHandler{level: level, next: next(level)}
 }
 
+// WithKafkaHandler отправляет записи в Kafka. Уровень регистрируется под именем "kafka".
+// Для нескольких обработчиков Kafka с разными уровнями используется
+// WithNamedLevel(name, level, option.NewHandler()) с отдельным именем для каждого
 func WithKafkaHandler(option *kafkaHandler.Option) slog.Handler {
-	return option.NewHandler()
+	opt := *option
+	level := RegisterLevel("kafka", opt.Level)
+	opt.Level = level
+	return &levelHandler{level: level, next: opt.NewHandler()}
 }
 
+// WithSentryHandler отправляет записи в Sentry. Уровень регистрируется под именем "sentry"
 func WithSentryHandler(level slog.Leveler, dsn, release, env string) slog.Handler {
 	if err := sentry.Init(sentry.ClientOptions{
 		Dsn:           dsn,
@@ -55,5 +78,46 @@ func WithSentryHandler(level slog.Leveler, dsn, release, env string) slog.Handle
 		panic(err)
 	}
 	defer sentry.Flush(2 * time.Second)
-	return sentryHandler.NewHandler(level, release, env)
+	v := RegisterLevel("sentry", level)
+	return &levelHandler{level: v, next: sentryHandler.NewHandler(v, release, env)}
+}
+
+// WithSpanHandler дублирует записи уровня level и выше событиями в активный спан.
+// Уровень регистрируется под именем "span"
+func WithSpanHandler(level slog.Leveler) slog.Handler {
+	v := RegisterLevel("span", level)
+	return &levelHandler{level: v, next: spanHandler.NewHandler(v)}
+}
+
+// WithOTLPHandler отправляет записи в коллектор через OTel Logs SDK.
+// Накопленные записи отправляются при observability.Shutdown. Уровень регистрируется под именем "otlp"
+func WithOTLPHandler(option otlpHandler.Option) slog.Handler {
+	level := RegisterLevel("otlp", option.Level)
+	option.Level = level
+	handler, err := option.NewHandler(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return &levelHandler{level: level, next: handler}
+}
+
+// WithLogfmtHandler пишет записи в формате logfmt. Уровень регистрируется под именем "logfmt"
+func WithLogfmtHandler(option logfmtHandler.Option) slog.Handler {
+	level := RegisterLevel("logfmt", option.Level)
+	option.Level = level
+	return &levelHandler{level: level, next: option.NewHandler()}
+}
+
+// WithECSHandler пишет записи в формате Elastic Common Schema. Уровень регистрируется под именем "ecs"
+func WithECSHandler(option ecsHandler.Option) slog.Handler {
+	level := RegisterLevel("ecs", option.Level)
+	option.Level = level
+	return &levelHandler{level: level, next: option.NewHandler()}
+}
+
+// WithGELFHandler пишет записи в формате GELF. Уровень регистрируется под именем "gelf"
+func WithGELFHandler(option gelfHandler.Option) slog.Handler {
+	level := RegisterLevel("gelf", option.Level)
+	option.Level = level
+	return &levelHandler{level: level, next: option.NewHandler()}
 }
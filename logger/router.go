@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"slices"
+)
+
+// Matcher условие маршрута. attrs содержит атрибуты из WithAttrs и записи,
+// атрибуты групп развернуты в ключи вида "group.key"
+type Matcher func(ctx context.Context, rec slog.Record, attrs []slog.Attr) bool
+
+// Transform изменяет запись перед передачей в обработчик маршрута. Запись содержит
+// атрибуты из WithAttrs, добавленные после последнего WithGroup; ключи атрибутов
+// указываются относительно текущей группы, без ее префикса
+type Transform func(ctx context.Context, rec slog.Record) slog.Record
+
+// Route маршрут записей в обработчик
+type Route struct {
+	handler    slog.Handler
+	matchers   []Matcher
+	transforms []Transform
+	final      bool
+}
+
+// NewRoute создает маршрут в handler для записей, подходящих под все matchers
+func NewRoute(handler slog.Handler, matchers ...Matcher) *Route {
+	return &Route{handler: handler, matchers: matchers}
+}
+
+// Transform добавляет преобразования записи. Применяются по порядку только к записям этого маршрута
+func (r *Route) Transform(transforms ...Transform) *Route {
+	r.transforms = append(r.transforms, transforms...)
+	return r
+}
+
+// Final останавливает обработку следующих маршрутов, если запись подошла под этот
+func (r *Route) Final() *Route {
+	r.final = true
+	return r
+}
+
+func (r *Route) match(ctx context.Context, rec slog.Record, attrs []slog.Attr) bool {
+	for _, m := range r.matchers {
+		if !m(ctx, rec, attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Route) with(handler slog.Handler) *Route {
+	next := *r
+	next.handler = handler
+	return &next
+}
+
+// Router slog.Handler, который передает запись в обработчики подходящих маршрутов
+// в порядке добавления, в отличие от slogmulti.Fanout, который передает запись во все обработчики
+type Router struct {
+	routes []*Route
+	attrs  []slog.Attr
+	group  string
+	// атрибуты WithAttrs текущей группы, добавляются в запись перед преобразованиями
+	pending []slog.Attr
+}
+
+// NewRouter создает обработчик с маршрутами routes
+func NewRouter(routes ...*Route) *Router {
+	return &Router{routes: routes}
+}
+
+func (h *Router) Enabled(ctx context.Context, lvl slog.Level) bool {
+	for _, r := range h.routes {
+		if r.handler.Enabled(ctx, lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Router) Handle(ctx context.Context, rec slog.Record) error {
+	attrs := slices.Clone(h.attrs)
+	rec.Attrs(func(attr slog.Attr) bool {
+		attrs = flattenAttr(attrs, h.group, attr)
+		return true
+	})
+
+	if len(h.pending) > 0 {
+		full := slog.NewRecord(rec.Time, rec.Level, rec.Message, rec.PC)
+		full.AddAttrs(h.pending...)
+		rec.Attrs(func(attr slog.Attr) bool {
+			full.AddAttrs(attr)
+			return true
+		})
+		rec = full
+	}
+
+	var errs []error
+	for _, r := range h.routes {
+		if !r.handler.Enabled(ctx, rec.Level) || !r.match(ctx, rec, attrs) {
+			continue
+		}
+		routed := rec.Clone()
+		for _, t := range r.transforms {
+			routed = t(ctx, routed)
+		}
+		if err := r.handler.Handle(ctx, routed); err != nil {
+			errs = append(errs, err)
+		}
+		if r.final {
+			break
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// WithAttrs сохраняет атрибуты в Router, а не в обработчиках маршрутов,
+// чтобы они были доступны преобразованиям маршрутов
+func (h *Router) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	next := &Router{
+		routes:  h.routes,
+		attrs:   slices.Clone(h.attrs),
+		group:   h.group,
+		pending: append(slices.Clone(h.pending), attrs...),
+	}
+	for _, attr := range attrs {
+		next.attrs = flattenAttr(next.attrs, h.group, attr)
+	}
+	return next
+}
+
+func (h *Router) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := &Router{routes: make([]*Route, 0, len(h.routes)), attrs: h.attrs, group: joinGroup(h.group, name)}
+	for _, r := range h.routes {
+		// атрибуты до группы не входят в нее, поэтому передаются в обработчики маршрутов
+		handler := r.handler
+		if len(h.pending) > 0 {
+			handler = handler.WithAttrs(h.pending)
+		}
+		next.routes = append(next.routes, r.with(handler.WithGroup(name)))
+	}
+	return next
+}
+
+// Shutdown останавливает обработчики маршрутов с методом Shutdown
+func (h *Router) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, r := range h.routes {
+		handler := r.handler
+		for handler != nil {
+			if s, ok := handler.(interface{ Shutdown(context.Context) error }); ok {
+				errs = append(errs, s.Shutdown(ctx))
+				break
+			}
+			u, ok := handler.(interface{ Unwrap() slog.Handler })
+			if !ok {
+				break
+			}
+			handler = u.Unwrap()
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// MatchLevel выбирает записи уровня level и выше
+func MatchLevel(level slog.Level) Matcher {
+	return func(_ context.Context, rec slog.Record, _ []slog.Attr) bool {
+		return rec.Level >= level
+	}
+}
+
+// MatchAttr выбирает записи с атрибутом key
+func MatchAttr(key string) Matcher {
+	return func(_ context.Context, _ slog.Record, attrs []slog.Attr) bool {
+		_, ok := findAttr(attrs, key)
+		return ok
+	}
+}
+
+// MatchAttrValue выбирает записи с атрибутом key, равным value
+func MatchAttrValue(key string, value slog.Value) Matcher {
+	return func(_ context.Context, _ slog.Record, attrs []slog.Attr) bool {
+		v, ok := findAttr(attrs, key)
+		return ok && v.Equal(value.Resolve())
+	}
+}
+
+// Not инвертирует условие
+func Not(m Matcher) Matcher {
+	return func(ctx context.Context, rec slog.Record, attrs []slog.Attr) bool {
+		return !m(ctx, rec, attrs)
+	}
+}
+
+// MapAttrs заменяет атрибуты записи результатом fn. Атрибут с пустым ключом удаляется
+func MapAttrs(fn func(attr slog.Attr) slog.Attr) Transform {
+	return func(_ context.Context, rec slog.Record) slog.Record {
+		next := slog.NewRecord(rec.Time, rec.Level, rec.Message, rec.PC)
+		rec.Attrs(func(attr slog.Attr) bool {
+			if attr = fn(attr); attr.Key != "" {
+				next.AddAttrs(attr)
+			}
+			return true
+		})
+		return next
+	}
+}
+
+// DropAttrs удаляет атрибуты записи с ключами keys
+func DropAttrs(keys ...string) Transform {
+	return MapAttrs(func(attr slog.Attr) slog.Attr {
+		if slices.Contains(keys, attr.Key) {
+			return slog.Attr{}
+		}
+		return attr
+	})
+}
+
+// RenameAttr переименовывает атрибут записи from в to
+func RenameAttr(from, to string) Transform {
+	return MapAttrs(func(attr slog.Attr) slog.Attr {
+		if attr.Key == from {
+			attr.Key = to
+		}
+		return attr
+	})
+}
+
+// AddAttrs добавляет атрибуты в запись
+func AddAttrs(attrs ...slog.Attr) Transform {
+	return func(_ context.Context, rec slog.Record) slog.Record {
+		rec.AddAttrs(attrs...)
+		return rec
+	}
+}
+
+func findAttr(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i].Key == key {
+			return attrs[i].Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+func flattenAttr(attrs []slog.Attr, group string, attr slog.Attr) []slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		prefix := joinGroup(group, attr.Key)
+		for _, nested := range attr.Value.Group() {
+			attrs = flattenAttr(attrs, prefix, nested)
+		}
+		return attrs
+	}
+	attr.Key = joinGroup(group, attr.Key)
+	return append(attrs, attr)
+}
+
+func joinGroup(group, key string) string {
+	if group == "" {
+		return key
+	}
+	if key == "" {
+		return group
+	}
+	return group + "." + key
+}
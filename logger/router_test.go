@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var res []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		res = append(res, m)
+	}
+	return res
+}
+
+func TestRouter(t *testing.T) {
+	errorsBuf, auditBuf, stdoutBuf := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	require.NoError(t, SetupLogger(NewRouter(
+		NewRoute(slog.NewJSONHandler(errorsBuf, opts), MatchLevel(slog.LevelError), MatchAttr("user_id")),
+		NewRoute(slog.NewJSONHandler(auditBuf, opts), MatchAttrValue("audit", slog.BoolValue(true))).
+			Transform(DropAttrs("audit"), RenameAttr("id", "entity_id"), AddAttrs(slog.String("topic", "audit"))).
+			Final(),
+		NewRoute(slog.NewJSONHandler(stdoutBuf, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	)))
+	ctx := context.Background()
+
+	Debug(ctx, "debug")
+	Info(ctx, "info")
+	Info(ctx, "changed", slog.Bool("audit", true), keyID(7))
+	Error(ctx, assert.AnError)
+	slog.Default().With(keyUserID("42")).ErrorContext(ctx, "user error")
+
+	errs := decodeLines(t, errorsBuf)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "user error", errs[0]["msg"])
+
+	audit := decodeLines(t, auditBuf)
+	require.Len(t, audit, 1)
+	assert.Equal(t, "changed", audit[0]["msg"])
+	assert.NotContains(t, audit[0], "audit")
+	assert.Equal(t, float64(7), audit[0]["entity_id"])
+	assert.Equal(t, "audit", audit[0]["topic"])
+
+	var messages []any
+	for _, m := range decodeLines(t, stdoutBuf) {
+		messages = append(messages, m["msg"])
+	}
+	assert.Equal(t, []any{"info", assert.AnError.Error(), "user error"}, messages)
+}
+
+func TestRouterGroups(t *testing.T) {
+	buf := new(bytes.Buffer)
+	router := NewRouter(NewRoute(slog.NewJSONHandler(buf, nil), MatchAttrValue("req.method", slog.StringValue("POST"))))
+	log := slog.New(router).WithGroup("req")
+
+	log.Info("get", slog.String("method", "GET"))
+	log.Info("post", slog.String("method", "POST"))
+	log.With(slog.String("method", "POST")).Info("with")
+
+	lines := decodeLines(t, buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "post", lines[0]["msg"])
+	assert.Equal(t, "with", lines[1]["msg"])
+}
+
+func TestRouterWithAttrsTransform(t *testing.T) {
+	buf := new(bytes.Buffer)
+	router := NewRouter(NewRoute(slog.NewJSONHandler(buf, nil)).Transform(DropAttrs("user_id"), RenameAttr("id", "entity_id")))
+	log := slog.New(router).With(slog.String("service", "api")).WithGroup("req").With(slog.String("user_id", "42"), slog.Int("id", 7))
+
+	log.Info("with", slog.String("method", "GET"))
+
+	lines := decodeLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "api", lines[0]["service"])
+	req := lines[0]["req"].(map[string]any)
+	assert.NotContains(t, req, "user_id")
+	assert.Equal(t, float64(7), req["entity_id"])
+	assert.Equal(t, "GET", req["method"])
+}
@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/MikL9/observability/metrics"
+)
+
+const (
+	samplerSlots = 4096
+
+	dropReasonRatio = "ratio"
+	dropReasonBurst = "burst"
+)
+
+// sampler сэмплер записей, установленный через SetSampler
+var sampler atomic.Pointer[Sampler]
+
+// SetSampler включает сэмплирование записей в Log. nil отключает сэмплирование
+func SetSampler(s *Sampler) {
+	sampler.Store(s)
+}
+
+// GetSampler возвращает сэмплер, установленный через SetSampler
+func GetSampler() *Sampler {
+	return sampler.Load()
+}
+
+// Sampler отбрасывает часть записей, чтобы частые сообщения не перегружали доставку логов.
+// Записи уровня keepLevel и выше сохраняются всегда
+type Sampler struct {
+	keepLevel       slog.Level
+	ratios          map[slog.Level]float64
+	first           uint64
+	thereafter      uint64
+	interval        time.Duration
+	traceConsistent bool
+	provider        metrics.Provider
+
+	counters [samplerSlots]sampleCounter
+	dropped  metrics.Counter
+}
+
+type SamplingOption func(s *Sampler)
+
+// WithBurst сохраняет первые first записей с одинаковыми уровнем и сообщением за interval,
+// затем каждую thereafter-ю. При thereafter равном 0 остальные записи отбрасываются
+func WithBurst(first, thereafter int, interval time.Duration) SamplingOption {
+	return func(s *Sampler) {
+		s.first = uint64(first)
+		s.thereafter = uint64(thereafter)
+		s.interval = interval
+	}
+}
+
+// WithLevelRatio сохраняет долю ratio записей уровня level
+func WithLevelRatio(level slog.Level, ratio float64) SamplingOption {
+	return func(s *Sampler) {
+		s.ratios[level] = ratio
+	}
+}
+
+// WithKeepLevel задает уровень, начиная с которого записи сохраняются всегда, по умолчанию Error
+func WithKeepLevel(level slog.Level) SamplingOption {
+	return func(s *Sampler) {
+		s.keepLevel = level
+	}
+}
+
+// WithTraceConsistent сохраняет все записи сэмплированных трейсов, а для остальных трейсов
+// принимает решение по доле из WithLevelRatio на основе trace_id, чтобы записи одного
+// трейса сохранялись или отбрасывались вместе
+func WithTraceConsistent() SamplingOption {
+	return func(s *Sampler) {
+		s.traceConsistent = true
+	}
+}
+
+// WithSamplingMetrics задает провайдер для счетчика отброшенных записей, по умолчанию metrics.Default
+func WithSamplingMetrics(provider metrics.Provider) SamplingOption {
+	return func(s *Sampler) {
+		s.provider = provider
+	}
+}
+
+// NewSampler создает сэмплер и счетчик logger_dropped_records_total{level,reason}.
+// Вызывается один раз на registry
+func NewSampler(opts ...SamplingOption) *Sampler {
+	s := &Sampler{
+		keepLevel: slog.LevelError,
+		ratios:    make(map[slog.Level]float64),
+		interval:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.provider == nil {
+		s.provider = metrics.Default()
+	}
+	s.dropped = s.provider.Counter(metrics.Opts{
+		Namespace: "logger",
+		Name:      "dropped_records_total",
+		Help:      "Количество записей, отброшенных сэмплированием",
+		Labels:    []string{"level", "reason"},
+	})
+	return s
+}
+
+// Sample возвращает true, если запись нужно сохранить
+func (s *Sampler) Sample(ctx context.Context, lvl slog.Level, msg string) bool {
+	if lvl >= s.keepLevel {
+		return true
+	}
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if s.traceConsistent && spanCtx.IsSampled() {
+		return true
+	}
+	if ratio, ok := s.ratios[lvl]; ok && !s.sampleRatio(spanCtx, ratio) {
+		s.dropped.Add(ctx, 1, lvl.String(), dropReasonRatio)
+		return false
+	}
+	if s.first > 0 && !s.sampleBurst(lvl, msg) {
+		s.dropped.Add(ctx, 1, lvl.String(), dropReasonBurst)
+		return false
+	}
+	return true
+}
+
+func (s *Sampler) sampleRatio(spanCtx trace.SpanContext, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	if s.traceConsistent && spanCtx.HasTraceID() {
+		// как в sdktrace.TraceIDRatioBased
+		traceID := spanCtx.TraceID()
+		return binary.BigEndian.Uint64(traceID[8:16])>>1 < uint64(ratio*(1<<63))
+	}
+	return rand.Float64() < ratio
+}
+
+func (s *Sampler) sampleBurst(lvl slog.Level, msg string) bool {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte{byte(lvl)})
+	_, _ = hash.Write([]byte(msg))
+	n := s.counters[hash.Sum32()%samplerSlots].inc(timeNow(), s.interval)
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// sampleCounter счетчик записей за текущий интервал
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+func (c *sampleCounter) inc(now time.Time, interval time.Duration) uint64 {
+	ts := now.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > ts {
+		return c.n.Add(1)
+	}
+	if !c.resetAt.CompareAndSwap(resetAt, ts+interval.Nanoseconds()) {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	return 1
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/MikL9/observability/metrics"
+)
+
+func TestSampler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, SetupLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	reg := prometheus.NewRegistry()
+	SetSampler(NewSampler(
+		WithBurst(2, 3, time.Second),
+		WithLevelRatio(slog.LevelDebug, 0),
+		WithTraceConsistent(),
+		WithSamplingMetrics(metrics.NewPrometheusProvider(reg)),
+	))
+	defer SetSampler(nil)
+
+	ctx := context.Background()
+	count := func(msg string) int {
+		defer buf.Reset()
+		return strings.Count(buf.String(), `"msg":"`+msg+`"`)
+	}
+
+	// первые 2, затем каждая 3-я
+	for range 8 {
+		Info(ctx, "request completed")
+	}
+	assert.Equal(t, 4, count("request completed"))
+
+	// новый интервал сбрасывает счетчик
+	now = now.Add(time.Second)
+	for range 2 {
+		Info(ctx, "request completed")
+	}
+	assert.Equal(t, 2, count("request completed"))
+
+	// ошибки сохраняются всегда
+	for range 5 {
+		Error(ctx, assert.AnError)
+	}
+	assert.Equal(t, 5, count(assert.AnError.Error()))
+
+	Debug(ctx, "debug")
+	assert.Zero(t, count("debug"))
+
+	// все записи сэмплированного трейса сохраняются
+	sampled := trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	for range 3 {
+		Debug(sampled, "debug")
+	}
+	assert.Equal(t, 3, count("debug"))
+
+	assert.Equal(t, 4.0, droppedRecords(t, reg, "INFO", dropReasonBurst))
+	assert.Equal(t, 1.0, droppedRecords(t, reg, "DEBUG", dropReasonRatio))
+}
+
+func TestSamplerTraceRatio(t *testing.T) {
+	s := NewSampler(
+		WithLevelRatio(slog.LevelInfo, 0.5),
+		WithTraceConsistent(),
+		WithSamplingMetrics(metrics.NewPrometheusProvider(prometheus.NewRegistry())),
+	)
+	for _, traceID := range []trace.TraceID{{1}, {15: 0xff}, {8: 0xff}} {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  trace.SpanID{1},
+		}))
+		first := s.Sample(ctx, slog.LevelInfo, "msg")
+		for range 10 {
+			assert.Equal(t, first, s.Sample(ctx, slog.LevelInfo, "msg"), traceID.String())
+		}
+	}
+}
+
+func droppedRecords(t *testing.T, reg *prometheus.Registry, level, reason string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "logger_dropped_records_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range m.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			if labels["level"] == level && labels["reason"] == reason {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+// Package metrics описывает инструменты метрик, которые могут работать поверх
+// Prometheus registerer или OTel MeterProvider.
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Opts описание инструмента. Значения меток передаются при записи в порядке Labels
+type Opts struct {
+	Namespace string
+	Subsystem string
+	Name      string
+	Help      string
+	Labels    []string
+	// Buckets границы гистограммы, по умолчанию prometheus.DefBuckets
+	Buckets []float64
+}
+
+// FullName имя метрики вида namespace_subsystem_name
+func (o Opts) FullName() string {
+	return prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)
+}
+
+// Counter монотонно растущий счетчик
+type Counter interface {
+	Add(ctx context.Context, v float64, labels ...string)
+}
+
+// UpDownCounter значение, которое может увеличиваться и уменьшаться, например число активных соединений
+type UpDownCounter interface {
+	Add(ctx context.Context, v float64, labels ...string)
+}
+
+// Histogram распределение значений. Если в ctx есть сэмплированный спан,
+// значение связывается с trace_id через exemplar
+type Histogram interface {
+	Observe(ctx context.Context, v float64, labels ...string)
+}
+
+// Provider создает инструменты
+type Provider interface {
+	Counter(opts Opts) Counter
+	UpDownCounter(opts Opts) UpDownCounter
+	Histogram(opts Opts) Histogram
+}
+
+var defaultProvider atomic.Pointer[Provider]
+
+// Default возвращает провайдер, установленный observability.Init,
+// или Prometheus поверх prometheus.DefaultRegisterer
+func Default() Provider {
+	if p := defaultProvider.Load(); p != nil {
+		return *p
+	}
+	return NewPrometheusProvider(prometheus.DefaultRegisterer)
+}
+
+// SetDefault устанавливает провайдер, возвращаемый Default
+func SetDefault(p Provider) {
+	if p == nil {
+		defaultProvider.Store(nil)
+		return
+	}
+	defaultProvider.Store(&p)
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testOpts = Opts{
+	Namespace: "http",
+	Subsystem: "test",
+	Name:      "requests_duration",
+	Labels:    []string{"path"},
+}
+
+func sampledContext() (context.Context, trace.SpanContext) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc), sc
+}
+
+func TestPrometheusProvider(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	provider := NewPrometheusProvider(reg)
+	ctx, sc := sampledContext()
+
+	provider.Histogram(testOpts).Observe(ctx, 0.2, "/users")
+	counter := provider.Counter(Opts{Namespace: "http", Subsystem: "test", Name: "requests_total"})
+	counter.Add(context.Background(), 2)
+	gauge := provider.UpDownCounter(Opts{Namespace: "http", Subsystem: "test", Name: "active"})
+	gauge.Add(ctx, 1)
+	gauge.Add(ctx, -1)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := map[string]any{}
+	for _, family := range families {
+		m := family.GetMetric()[0]
+		switch family.GetName() {
+		case "http_test_requests_duration":
+			h := m.GetHistogram()
+			values[family.GetName()] = h.GetSampleCount()
+			assert.Equal(t, "path", m.GetLabel()[0].GetName())
+			var exemplars int
+			for _, b := range h.GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					exemplars++
+					assert.Equal(t, sc.TraceID().String(), e.GetLabel()[0].GetValue())
+				}
+			}
+			assert.Equal(t, 1, exemplars)
+		case "http_test_requests_total":
+			values[family.GetName()] = m.GetCounter().GetValue()
+			assert.Nil(t, m.GetCounter().GetExemplar())
+		case "http_test_active":
+			values[family.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]any{
+		"http_test_requests_duration": uint64(1),
+		"http_test_requests_total":    2.0,
+		"http_test_active":            0.0,
+	}, values)
+}
+
+func TestOTelProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := NewOTelProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	ctx, sc := sampledContext()
+
+	provider.Histogram(testOpts).Observe(ctx, 0.2, "/users")
+	provider.Counter(Opts{Namespace: "http", Subsystem: "test", Name: "requests_total", Labels: []string{"status"}}).
+		Add(ctx, 1, "200")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	byName := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+
+	histogram := byName["http_test_requests_duration"].Data.(metricdata.Histogram[float64])
+	require.Len(t, histogram.DataPoints, 1)
+	point := histogram.DataPoints[0]
+	assert.Equal(t, uint64(1), point.Count)
+	assert.Equal(t, attribute.NewSet(attribute.String("path", "/users")), point.Attributes)
+	require.Len(t, point.Exemplars, 1)
+	assert.Equal(t, sc.TraceID(), trace.TraceID(point.Exemplars[0].TraceID))
+
+	sum := byName["http_test_requests_total"].Data.(metricdata.Sum[float64])
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, 1.0, sum.DataPoints[0].Value)
+}
+
+func TestDefault(t *testing.T) {
+	provider := NewPrometheusProvider(prometheus.NewRegistry())
+	SetDefault(provider)
+	defer SetDefault(nil)
+	assert.Same(t, provider, Default())
+}
+
+func TestPrometheusNativeHistograms(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	NewPrometheusProvider(reg, WithNativeHistograms(1.1)).Histogram(testOpts).Observe(context.Background(), 0.2, "/users")
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	h := families[0].GetMetric()[0].GetHistogram()
+	assert.NotEmpty(t, h.GetBucket(), "classic buckets are kept")
+	assert.NotZero(t, h.GetSchema())
+	assert.Equal(t, int64(1), h.GetPositiveDelta()[0])
+}
+
+func TestPrometheusProviderReregister(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := Opts{Namespace: "http", Subsystem: "test", Name: "retries_total"}
+	NewPrometheusProvider(reg).Counter(opts).Add(context.Background(), 1)
+	NewPrometheusProvider(reg).Counter(opts).Add(context.Background(), 2)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, 3.0, families[0].GetMetric()[0].GetCounter().GetValue())
+
+	assert.Panics(t, func() {
+		NewPrometheusProvider(reg).Counter(Opts{Namespace: "http", Subsystem: "test", Name: "retries_total", Labels: []string{"path"}})
+	})
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/MikL9/observability"
+
+type otelProvider struct {
+	meter metric.Meter
+}
+
+// NewOTelProvider создает инструменты в mp. Имя инструмента совпадает с именем метрики Prometheus,
+// метки передаются атрибутами. Exemplars добавляются SDK из переданного контекста
+func NewOTelProvider(mp metric.MeterProvider) Provider {
+	return &otelProvider{meter: mp.Meter(meterName)}
+}
+
+func (p *otelProvider) Counter(opts Opts) Counter {
+	counter, err := p.meter.Float64Counter(opts.FullName(), metric.WithDescription(opts.Help))
+	if err != nil {
+		panic(err)
+	}
+	return &otelCounter{counter: counter, labels: opts.Labels}
+}
+
+func (p *otelProvider) UpDownCounter(opts Opts) UpDownCounter {
+	counter, err := p.meter.Float64UpDownCounter(opts.FullName(), metric.WithDescription(opts.Help))
+	if err != nil {
+		panic(err)
+	}
+	return &otelUpDownCounter{counter: counter, labels: opts.Labels}
+}
+
+func (p *otelProvider) Histogram(opts Opts) Histogram {
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	histogram, err := p.meter.Float64Histogram(opts.FullName(),
+		metric.WithDescription(opts.Help),
+		metric.WithExplicitBucketBoundaries(buckets...),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return &otelHistogram{histogram: histogram, labels: opts.Labels}
+}
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	labels  []string
+}
+
+func (c *otelCounter) Add(ctx context.Context, v float64, labels ...string) {
+	c.counter.Add(contextOrBackground(ctx), v, metric.WithAttributeSet(attributeSet(c.labels, labels)))
+}
+
+type otelUpDownCounter struct {
+	counter metric.Float64UpDownCounter
+	labels  []string
+}
+
+func (c *otelUpDownCounter) Add(ctx context.Context, v float64, labels ...string) {
+	c.counter.Add(contextOrBackground(ctx), v, metric.WithAttributeSet(attributeSet(c.labels, labels)))
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	labels    []string
+}
+
+func (h *otelHistogram) Observe(ctx context.Context, v float64, labels ...string) {
+	h.histogram.Record(contextOrBackground(ctx), v, metric.WithAttributeSet(attributeSet(h.labels, labels)))
+}
+
+func attributeSet(names, values []string) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(names))
+	for i, name := range names {
+		var v string
+		if i < len(values) {
+			v = values[i]
+		}
+		kvs = append(kvs, attribute.String(name, v))
+	}
+	return attribute.NewSet(kvs...)
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MikL9/observability/otlpconfig"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+)
+
+type otlpOptions struct {
+	protocol    otlpconfig.Protocol
+	tls         *tls.Config
+	headers     map[string]string
+	compression otlpconfig.Compression
+	interval    time.Duration
+	resource    *sdkresource.Resource
+}
+
+// OTLPOption определяет функцию для установки параметров экспорта метрик по OTLP
+type OTLPOption func(*otlpOptions)
+
+// WithProtocol устанавливает протокол: otlpconfig.ProtocolGRPC (по умолчанию) или otlpconfig.ProtocolHTTP
+func WithProtocol(protocol otlpconfig.Protocol) OTLPOption {
+	return func(o *otlpOptions) {
+		o.protocol = protocol
+	}
+}
+
+// WithTLS включает TLS при подключении к коллектору
+func WithTLS(config *tls.Config) OTLPOption {
+	return func(o *otlpOptions) {
+		o.tls = config
+	}
+}
+
+// WithHeaders добавляет заголовки к каждому запросу в коллектор
+func WithHeaders(headers map[string]string) OTLPOption {
+	return func(o *otlpOptions) {
+		o.headers = headers
+	}
+}
+
+// WithCompression устанавливает сжатие данных при отправке в коллектор
+func WithCompression(compression otlpconfig.Compression) OTLPOption {
+	return func(o *otlpOptions) {
+		o.compression = compression
+	}
+}
+
+// WithInterval устанавливает период отправки метрик, по умолчанию 60s
+func WithInterval(interval time.Duration) OTLPOption {
+	return func(o *otlpOptions) {
+		o.interval = interval
+	}
+}
+
+// WithResource устанавливает resource, общий с трейсами и логами
+func WithResource(res *sdkresource.Resource) OTLPOption {
+	return func(o *otlpOptions) {
+		o.resource = res
+	}
+}
+
+// NewOTLPMeterProvider создает MeterProvider, периодически отправляющий метрики в endpoint
+func NewOTLPMeterProvider(ctx context.Context, endpoint string, opts ...OTLPOption) (*sdkmetric.MeterProvider, error) {
+	o := &otlpOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	exporter, err := newOTLPExporter(ctx, endpoint, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if o.interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(o.interval))
+	}
+	providerOpts := []sdkmetric.Option{sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...))}
+	if o.resource != nil {
+		providerOpts = append(providerOpts, sdkmetric.WithResource(o.resource))
+	}
+	return sdkmetric.NewMeterProvider(providerOpts...), nil
+}
+
+func newOTLPExporter(ctx context.Context, endpoint string, o *otlpOptions) (sdkmetric.Exporter, error) {
+	withURL := strings.Contains(endpoint, "://")
+
+	switch o.protocol {
+	case otlpconfig.ProtocolHTTP:
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+		if withURL {
+			opts = []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(endpoint)}
+		}
+		if o.tls != nil {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(o.tls))
+		} else if !strings.HasPrefix(endpoint, "https://") {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(o.headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(o.headers))
+		}
+		if o.compression == otlpconfig.CompressionGzip {
+			opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+		}
+		exporter, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlpmetrichttp.New: %w", err)
+		}
+		return exporter, nil
+	case otlpconfig.ProtocolGRPC, "":
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint)}
+		if withURL {
+			opts = []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpointURL(endpoint)}
+		}
+		if o.tls != nil {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(o.tls)))
+		} else if !strings.HasPrefix(endpoint, "https://") {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(o.headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(o.headers))
+		}
+		if o.compression == otlpconfig.CompressionGzip {
+			opts = append(opts, otlpmetricgrpc.WithCompressor(string(otlpconfig.CompressionGzip)))
+		}
+		exporter, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlpmetricgrpc.New: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("metrics: unknown protocol %q", o.protocol)
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const exemplarTraceIDKey = "trace_id"
+
+const (
+	nativeHistogramMaxBuckets   = 160
+	nativeHistogramResetTimeout = time.Hour
+)
+
+type prometheusProvider struct {
+	reg prometheus.Registerer
+
+	nativeBucketFactor float64
+}
+
+// PrometheusOption определяет функцию для установки параметров Prometheus провайдера
+type PrometheusOption func(*prometheusProvider)
+
+// WithNativeHistograms включает native histograms с ростом границ бакетов не более чем
+// в bucketFactor раз, например 1.1. Классические бакеты продолжают отдаваться для
+// совместимости, native histograms доступны при сборе метрик в protobuf формате
+func WithNativeHistograms(bucketFactor float64) PrometheusOption {
+	return func(p *prometheusProvider) {
+		p.nativeBucketFactor = bucketFactor
+	}
+}
+
+// NewPrometheusProvider создает инструменты в reg. Повторная регистрация метрики с тем же именем
+// и метками возвращает уже зарегистрированную метрику, например для двух клиентов с одним serviceID.
+// Гистограммы и счетчики сохраняют trace_id сэмплированного спана в exemplar, они отдаются в формате OpenMetrics
+func NewPrometheusProvider(reg prometheus.Registerer, opts ...PrometheusOption) Provider {
+	p := &prometheusProvider{reg: reg}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *prometheusProvider) Counter(opts Opts) Counter {
+	return &promCounter{vec: register(p.reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      opts.Name,
+		Help:      opts.Help,
+	}, opts.Labels))}
+}
+
+func (p *prometheusProvider) UpDownCounter(opts Opts) UpDownCounter {
+	return &promGauge{vec: register(p.reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      opts.Name,
+		Help:      opts.Help,
+	}, opts.Labels))}
+}
+
+func (p *prometheusProvider) Histogram(opts Opts) Histogram {
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	histogramOpts := prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      opts.Name,
+		Help:      opts.Help,
+		Buckets:   buckets,
+	}
+	if p.nativeBucketFactor > 1 {
+		histogramOpts.NativeHistogramBucketFactor = p.nativeBucketFactor
+		histogramOpts.NativeHistogramMaxBucketNumber = nativeHistogramMaxBuckets
+		histogramOpts.NativeHistogramMinResetDuration = nativeHistogramResetTimeout
+	}
+	return &promHistogram{vec: register(p.reg, prometheus.NewHistogramVec(histogramOpts, opts.Labels))}
+}
+
+// register регистрирует collector в reg. Если такая метрика уже зарегистрирована, возвращает ее,
+// при других ошибках паникует, как и promauto
+func register[T prometheus.Collector](reg prometheus.Registerer, collector T) T {
+	if reg == nil {
+		return collector
+	}
+	if err := reg.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return collector
+}
+
+type promCounter struct {
+	vec *prometheus.CounterVec
+}
+
+func (c *promCounter) Add(ctx context.Context, v float64, labels ...string) {
+	counter := c.vec.WithLabelValues(labels...)
+	if exemplar := exemplarLabels(ctx); exemplar != nil {
+		counter.(prometheus.ExemplarAdder).AddWithExemplar(v, exemplar)
+		return
+	}
+	counter.Add(v)
+}
+
+type promGauge struct {
+	vec *prometheus.GaugeVec
+}
+
+func (g *promGauge) Add(_ context.Context, v float64, labels ...string) {
+	g.vec.WithLabelValues(labels...).Add(v)
+}
+
+type promHistogram struct {
+	vec *prometheus.HistogramVec
+}
+
+func (h *promHistogram) Observe(ctx context.Context, v float64, labels ...string) {
+	observer := h.vec.WithLabelValues(labels...)
+	if exemplar := exemplarLabels(ctx); exemplar != nil {
+		observer.(prometheus.ExemplarObserver).ObserveWithExemplar(v, exemplar)
+		return
+	}
+	observer.Observe(v)
+}
+
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	if ctx == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{exemplarTraceIDKey: sc.TraceID().String()}
+}
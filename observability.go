@@ -2,22 +2,34 @@ package observability
 
 import (
 	"context"
+	stderrors "errors"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/MikL9/observability/logger"
 	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/metrics"
 	"github.com/MikL9/observability/tracing"
 	"github.com/MikL9/observability/utils"
 	"go.opentelemetry.io/otel/attribute"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	"go.opentelemetry.io/otel/trace"
 )
 
 type Observability struct {
 	prometheus    prometheus.Registerer
 	traceProvider *sdktrace.TracerProvider
+	traceEndpoint string
+	resource      *sdkresource.Resource
+	metrics       metrics.Provider
 	serviceID     string
 	logFormat     string
+	shutdown      []func(context.Context) error
+
+	// nativeHistogramFactor включает native histograms в Prometheus провайдере
+	nativeHistogramFactor float64
+	// config дополнительные конфигурации для /debug/config
+	config map[string]any
 }
 
 var instance *Observability
@@ -34,27 +46,80 @@ func Init(serviceID string, opts ...Option) error {
 			return err
 		}
 	}
+	metrics.SetDefault(GetMetrics())
 	return nil
 }
 
+// Snapshot запоминает текущее состояние, заданное через Init, и уровни логов и возвращает функцию для его восстановления
+func Snapshot() (restore func()) {
+	prev := instance
+	prevMetrics := metrics.Default()
+	prevSampler := logger.GetSampler()
+	restoreLevels := logger.SnapshotLevels()
+	return func() {
+		instance = prev
+		metrics.SetDefault(prevMetrics)
+		logger.SetSampler(prevSampler)
+		restoreLevels()
+	}
+}
+
 func GetRegisterer() prometheus.Registerer {
 	return instance.prometheus
 }
 
-func Start(ctx *context.Context) *tracing.SpanWrapper {
+// GetMetrics возвращает провайдер метрик, заданный через WithMetrics или WithOTLPMetrics,
+// иначе Prometheus поверх GetRegisterer
+func GetMetrics() metrics.Provider {
+	if instance == nil {
+		return metrics.Default()
+	}
+	if instance.metrics != nil {
+		return instance.metrics
+	}
+	var opts []metrics.PrometheusOption
+	if instance.nativeHistogramFactor > 0 {
+		opts = append(opts, metrics.WithNativeHistograms(instance.nativeHistogramFactor))
+	}
+	return metrics.NewPrometheusProvider(instance.prometheus, opts...)
+}
+
+// Shutdown останавливает trace provider, meter provider и обработчики логов с методом Shutdown,
+// отправляя накопленные данные. Вызывается при завершении приложения
+func Shutdown(ctx context.Context) error {
+	if instance == nil {
+		return nil
+	}
+	var errs []error
+	for i := len(instance.shutdown) - 1; i >= 0; i-- {
+		if err := instance.shutdown[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// GetResource возвращает resource, заданный через WithResource, или nil
+func GetResource() *sdkresource.Resource {
+	if instance == nil {
+		return nil
+	}
+	return instance.resource
+}
+
+// Start создает спан с именем вызывающей функции. Тип спана, ссылки и атрибуты задаются через opts
+func Start(ctx *context.Context, opts ...tracing.SpanOption) *tracing.SpanWrapper {
 	caller := utils.GetOriginalCallerFuncName(3)
-	spanWrapper := tracing.New(ctx, utils.GetOpNameBySnakeCase(caller))
+	spanWrapper := tracing.New(ctx, utils.GetOpNameBySnakeCase(caller), opts...)
 	spanWrapper.Start()
-	*ctx = trace.ContextWithSpanContext(*ctx, spanWrapper.Span.SpanContext())
 	spanWrapper.Span.SetAttributes(attribute.String("caller", caller))
 	return spanWrapper
 }
 
-func StartWithName(ctx *context.Context, name string) *tracing.SpanWrapper {
+func StartWithName(ctx *context.Context, name string, opts ...tracing.SpanOption) *tracing.SpanWrapper {
 	caller := utils.GetOriginalCallerFuncName(3)
-	spanWrapper := tracing.New(ctx, name)
+	spanWrapper := tracing.New(ctx, name, opts...)
 	spanWrapper.Start()
-	*ctx = trace.ContextWithSpanContext(*ctx, spanWrapper.Span.SpanContext())
 	spanWrapper.Span.SetAttributes(attribute.String("caller", caller))
 	return spanWrapper
 }
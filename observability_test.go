@@ -2,11 +2,17 @@ package observability
 
 import (
 	"context"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/MikL9/observability/logger"
 	"github.com/MikL9/observability/logger/errors"
 )
 
@@ -40,3 +46,34 @@ func TestWrapPrefixError(t *testing.T) {
 	assert.True(t, ok, "failed to convert observability Error object")
 	assert.NotEmpty(t, errObj.ErrorStack())
 }
+
+func TestStartSpanHandler(t *testing.T) {
+	prevProvider := otel.GetTracerProvider()
+	prevLogger := slog.Default()
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		slog.SetDefault(prevLogger)
+	}()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	require.NoError(t, logger.SetupLogger(logger.WithSpanHandler(slog.LevelInfo)))
+
+	ctx := context.Background()
+	span := Start(&ctx)
+	assert.True(t, trace.SpanFromContext(ctx).IsRecording())
+	logger.Info(ctx, "inside start")
+	span.End()
+
+	ctx = context.Background()
+	span = StartWithName(&ctx, "named")
+	logger.Info(ctx, "inside named")
+	span.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+	for i, msg := range []string{"inside start", "inside named"} {
+		require.Len(t, ended[i].Events(), 1)
+		assert.Equal(t, msg, ended[i].Events()[0].Name)
+	}
+}
@@ -0,0 +1,123 @@
+package observabilitytest
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record запись лога, сохраненная LogRecorder. Атрибуты групп разворачиваются в ключи вида "group.key"
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// Attr возвращает значение атрибута по ключу
+func (r Record) Attr(key string) (slog.Value, bool) {
+	for _, attr := range r.Attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// LogRecorder slog.Handler, который сохраняет все записи в памяти
+type LogRecorder struct {
+	store *logStore
+	attrs []slog.Attr
+	group string
+}
+
+type logStore struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func NewLogRecorder() *LogRecorder {
+	return &LogRecorder{store: &logStore{}}
+}
+
+func (h *LogRecorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *LogRecorder) Handle(_ context.Context, rec slog.Record) error {
+	attrs := slices.Clone(h.attrs)
+	rec.Attrs(func(attr slog.Attr) bool {
+		attrs = appendAttr(attrs, h.group, attr)
+		return true
+	})
+
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	h.store.records = append(h.store.records, Record{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Attrs:   attrs,
+	})
+	return nil
+}
+
+func (h *LogRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = slices.Clone(h.attrs)
+	for _, attr := range attrs {
+		next.attrs = appendAttr(next.attrs, h.group, attr)
+	}
+	return &next
+}
+
+func (h *LogRecorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.group = joinKey(h.group, name)
+	return &next
+}
+
+// Records возвращает копию сохраненных записей
+func (h *LogRecorder) Records() []Record {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	return slices.Clone(h.store.records)
+}
+
+// Reset удаляет сохраненные записи
+func (h *LogRecorder) Reset() {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	h.store.records = nil
+}
+
+func appendAttr(attrs []slog.Attr, group string, attr slog.Attr) []slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		prefix := joinKey(group, attr.Key)
+		for _, nested := range attr.Value.Group() {
+			attrs = appendAttr(attrs, prefix, nested)
+		}
+		return attrs
+	}
+	attr.Key = joinKey(group, attr.Key)
+	return append(attrs, attr)
+}
+
+func joinKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	if key == "" {
+		return group
+	}
+	return group + "." + key
+}
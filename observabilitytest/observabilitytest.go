@@ -0,0 +1,186 @@
+// Package observabilitytest подменяет глобальные логгер, трейсер и Prometheus registry
+// на хранилища в памяти, чтобы в тестах можно было проверять, что было залогировано,
+// какие спаны созданы и какие метрики изменены.
+package observabilitytest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/MikL9/observability"
+)
+
+const ServiceID = "test"
+
+// Sink хранилища в памяти, установленные в качестве глобальных
+type Sink struct {
+	Logs     *LogRecorder
+	Spans    *tracetest.SpanRecorder
+	Registry *prometheus.Registry
+}
+
+// New инициализирует observability с хранилищами в памяти. Дополнительные опции
+// применяются после установки хранилищ. Глобальное состояние восстанавливается в t.Cleanup
+func New(t testing.TB, opts ...observability.Option) *Sink {
+	t.Helper()
+
+	sink := &Sink{
+		Logs:     NewLogRecorder(),
+		Spans:    tracetest.NewSpanRecorder(),
+		Registry: prometheus.NewRegistry(),
+	}
+
+	restore := observability.Snapshot()
+	prevLogger := slog.Default()
+	prevProvider := otel.GetTracerProvider()
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sink.Spans))
+	otel.SetTracerProvider(provider)
+
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		slog.SetDefault(prevLogger)
+		restore()
+	})
+
+	opts = append([]observability.Option{
+		observability.WithPrometheus(sink.Registry),
+		observability.WithLoggerOptions(sink.Logs),
+	}, opts...)
+	if err := observability.Init(ServiceID, opts...); err != nil {
+		t.Fatalf("observabilitytest: init: %v", err)
+	}
+	return sink
+}
+
+// AssertLogged проверяет, что была запись с уровнем level, сообщением msg и всеми атрибутами attrs
+func (s *Sink) AssertLogged(t testing.TB, level slog.Level, msg string, attrs ...slog.Attr) Record {
+	t.Helper()
+	for _, rec := range s.Logs.Records() {
+		if rec.Level == level && rec.Message == msg && hasAttrs(rec, attrs) {
+			return rec
+		}
+	}
+	t.Errorf("observabilitytest: no %s record %q with attrs %v, got:\n%s", level, msg, attrs, formatRecords(s.Logs.Records()))
+	return Record{}
+}
+
+// AssertNotLogged проверяет, что записи с уровнем level и сообщением msg не было
+func (s *Sink) AssertNotLogged(t testing.TB, level slog.Level, msg string) {
+	t.Helper()
+	for _, rec := range s.Logs.Records() {
+		if rec.Level == level && rec.Message == msg {
+			t.Errorf("observabilitytest: unexpected %s record %q", level, msg)
+			return
+		}
+	}
+}
+
+// AssertSpan проверяет, что был завершен спан с именем name, и возвращает последний из них
+func (s *Sink) AssertSpan(t testing.TB, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	spans := s.Spans.Ended()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Name() == name {
+			return spans[i]
+		}
+	}
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	t.Errorf("observabilitytest: no ended span %q, got %v", name, names)
+	return nil
+}
+
+// CounterValue возвращает значение счетчика name с метками labels. Отсутствующий счетчик равен 0
+func (s *Sink) CounterValue(t testing.TB, name string, labels map[string]string) float64 {
+	t.Helper()
+	if m := s.metric(t, name, labels); m != nil && m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+	return 0
+}
+
+// GaugeValue возвращает значение gauge name с метками labels. Отсутствующий gauge равен 0
+func (s *Sink) GaugeValue(t testing.TB, name string, labels map[string]string) float64 {
+	t.Helper()
+	if m := s.metric(t, name, labels); m != nil && m.GetGauge() != nil {
+		return m.GetGauge().GetValue()
+	}
+	return 0
+}
+
+// HistogramCount возвращает количество наблюдений гистограммы name с метками labels
+func (s *Sink) HistogramCount(t testing.TB, name string, labels map[string]string) uint64 {
+	t.Helper()
+	if m := s.metric(t, name, labels); m != nil && m.GetHistogram() != nil {
+		return m.GetHistogram().GetSampleCount()
+	}
+	return 0
+}
+
+func (s *Sink) metric(t testing.TB, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := s.Registry.Gather()
+	if err != nil {
+		t.Fatalf("observabilitytest: gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if labelsMatch(m, labels) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func labelsMatch(m *dto.Metric, labels map[string]string) bool {
+	if len(m.GetLabel()) != len(labels) {
+		return false
+	}
+	for _, pair := range m.GetLabel() {
+		if v, ok := labels[pair.GetName()]; !ok || v != pair.GetValue() {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAttrs(rec Record, attrs []slog.Attr) bool {
+	for _, attr := range attrs {
+		v, ok := rec.Attr(attr.Key)
+		if !ok || !v.Equal(attr.Value.Resolve()) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatRecords(records []Record) string {
+	lines := make([]string, 0, len(records))
+	for _, rec := range records {
+		attrs := make([]string, 0, len(rec.Attrs))
+		for _, attr := range rec.Attrs {
+			attrs = append(attrs, attr.String())
+		}
+		sort.Strings(attrs)
+		lines = append(lines, fmt.Sprintf("\t%s %q %s", rec.Level, rec.Message, strings.Join(attrs, " ")))
+	}
+	return strings.Join(lines, "\n")
+}
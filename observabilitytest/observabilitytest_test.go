@@ -0,0 +1,74 @@
+package observabilitytest
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/MikL9/observability"
+	"github.com/MikL9/observability/logger"
+)
+
+func doWork(ctx context.Context) (err error) {
+	span := observability.Start(&ctx)
+	defer span.StopWrap(&err)
+	logger.Info(ctx, "work done", slog.Int("items", 3))
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	prevLogger := slog.Default()
+	prevProvider := otel.GetTracerProvider()
+
+	t.Run("record", func(t *testing.T) {
+		sink := New(t)
+
+		counter := promauto.With(observability.GetRegisterer()).NewCounterVec(prometheus.CounterOpts{
+			Name: "work_total",
+		}, []string{"status"})
+
+		require.NoError(t, doWork(context.Background()))
+		counter.WithLabelValues("ok").Add(2)
+
+		rec := sink.AssertLogged(t, slog.LevelInfo, "work done", slog.Int("items", 3))
+		_, ok := rec.Attr("trace_id")
+		assert.True(t, ok)
+
+		span := sink.AssertSpan(t, "do_work")
+		require.NotNil(t, span)
+
+		assert.Equal(t, 2.0, sink.CounterValue(t, "work_total", map[string]string{"status": "ok"}))
+		assert.Zero(t, sink.CounterValue(t, "work_total", map[string]string{"status": "failed"}))
+		sink.AssertNotLogged(t, slog.LevelError, "work done")
+	})
+
+	assert.Same(t, prevLogger, slog.Default())
+	assert.Equal(t, prevProvider, otel.GetTracerProvider())
+}
+
+func TestLogRecorderGroups(t *testing.T) {
+	rec := NewLogRecorder()
+	log := slog.New(rec).With(slog.String("service", "api")).WithGroup("request")
+	log.Info("query", slog.String("method", "GET"), slog.Group("user", slog.Int("id", 1)))
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	for key, expected := range map[string]slog.Value{
+		"service":         slog.StringValue("api"),
+		"request.method":  slog.StringValue("GET"),
+		"request.user.id": slog.IntValue(1),
+	} {
+		v, ok := records[0].Attr(key)
+		assert.True(t, ok, key)
+		assert.True(t, expected.Equal(v), key)
+	}
+
+	rec.Reset()
+	assert.Empty(t, rec.Records())
+}
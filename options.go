@@ -9,28 +9,58 @@ import (
 	"github.com/MikL9/observability/hide"
 
 	"github.com/MikL9/observability/logger"
+	"github.com/MikL9/observability/metrics"
+	"github.com/MikL9/observability/resource"
 	"github.com/MikL9/observability/tracing"
 )
 
 type Option func(*Observability) error
 
+// shutdowner обработчик логов, которому нужно отправить накопленные записи при завершении
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
 func WithJaegerOptions(endpoint string, options ...tracing.TraceProviderOption) Option {
 	return func(o *Observability) error {
 		var err error
+		if o.resource != nil {
+			options = append([]tracing.TraceProviderOption{tracing.WithResource(o.resource)}, options...)
+		}
 		o.traceProvider, err = tracing.Init(context.Background(), o.serviceID, endpoint, options...)
-		return err
+		if err != nil {
+			return err
+		}
+		o.traceEndpoint = endpoint
+		o.shutdown = append(o.shutdown, o.traceProvider.Shutdown)
+		return nil
 	}
 }
 
 func WithLoggerOptions(handlers ...slog.Handler) Option {
 	return func(o *Observability) error {
+		for _, h := range handlers {
+			for h != nil {
+				if s, ok := h.(shutdowner); ok {
+					o.shutdown = append(o.shutdown, s.Shutdown)
+					break
+				}
+				u, ok := h.(interface{ Unwrap() slog.Handler })
+				if !ok {
+					break
+				}
+				h = u.Unwrap()
+			}
+		}
 		fanout := slogmulti.Fanout(handlers...)
 
 		handler := slog.Handler(fanout)
+		attrs := resource.LogAttrs(o.resource)
 		if o.logFormat != "" {
-			handler = handler.WithAttrs([]slog.Attr{
-				slog.String("log_format", o.logFormat),
-			})
+			attrs = append(attrs, slog.String("log_format", o.logFormat))
+		}
+		if len(attrs) > 0 {
+			handler = handler.WithAttrs(attrs)
 		}
 
 		return logger.SetupLogger(handler)
@@ -44,6 +74,40 @@ func WithPrometheus(prom prometheus.Registerer) Option {
 	}
 }
 
+// WithMetrics задает провайдер метрик для инструментации библиотеки вместо Prometheus из WithPrometheus
+func WithMetrics(provider metrics.Provider) Option {
+	return func(o *Observability) error {
+		o.metrics = provider
+		return nil
+	}
+}
+
+// WithNativeHistograms включает Prometheus native histograms для всех гистограмм библиотеки,
+// bucketFactor задает точность, например 1.1
+func WithNativeHistograms(bucketFactor float64) Option {
+	return func(o *Observability) error {
+		o.nativeHistogramFactor = bucketFactor
+		return nil
+	}
+}
+
+// WithOTLPMetrics отправляет метрики инструментации библиотеки по OTLP в endpoint.
+// Resource из WithResource используется, если WithResource указана раньше
+func WithOTLPMetrics(endpoint string, opts ...metrics.OTLPOption) Option {
+	return func(o *Observability) error {
+		if o.resource != nil {
+			opts = append([]metrics.OTLPOption{metrics.WithResource(o.resource)}, opts...)
+		}
+		mp, err := metrics.NewOTLPMeterProvider(context.Background(), endpoint, opts...)
+		if err != nil {
+			return err
+		}
+		o.metrics = metrics.NewOTelProvider(mp)
+		o.shutdown = append(o.shutdown, mp.Shutdown)
+		return nil
+	}
+}
+
 func WithHideConverter(convert *hide.Converter) Option {
 	return func(observability *Observability) error {
 		hide.SetDefaultConverter(convert)
@@ -51,6 +115,38 @@ func WithHideConverter(convert *hide.Converter) Option {
 	}
 }
 
+// WithResource задает идентичность сервиса (версию, окружение, Kubernetes, контейнер),
+// общую для трейсов и логов. Должна идти перед WithJaegerOptions и WithLoggerOptions
+func WithResource(opts ...resource.Option) Option {
+	return func(o *Observability) error {
+		var err error
+		o.resource, err = resource.New(context.Background(), o.serviceID, opts...)
+		return err
+	}
+}
+
+// WithLogSampling включает сэмплирование записей логгера. Счетчик отброшенных записей создается
+// в провайдере метрик, поэтому опция передается после WithPrometheus или WithMetrics
+func WithLogSampling(opts ...logger.SamplingOption) Option {
+	return func(o *Observability) error {
+		opts = append([]logger.SamplingOption{logger.WithSamplingMetrics(GetMetrics())}, opts...)
+		logger.SetSampler(logger.NewSampler(opts...))
+		return nil
+	}
+}
+
+// WithConfig добавляет конфигурацию под именем name в /debug/config обработчика диагностики.
+// Значение сериализуется в JSON, чувствительные поля маскируются через hide
+func WithConfig(name string, config any) Option {
+	return func(o *Observability) error {
+		if o.config == nil {
+			o.config = make(map[string]any)
+		}
+		o.config[name] = config
+		return nil
+	}
+}
+
 func WithLogFormat(format string) Option {
 	return func(o *Observability) error {
 		o.logFormat = format
@@ -0,0 +1,18 @@
+// Package otlpconfig общие настройки подключения к OTLP коллектору для трейсов, метрик и логов
+package otlpconfig
+
+// Protocol протокол отправки данных в коллектор
+type Protocol string
+
+const (
+	ProtocolGRPC Protocol = "grpc"
+	ProtocolHTTP Protocol = "http"
+)
+
+// Compression сжатие данных при отправке в коллектор
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+)
@@ -0,0 +1,190 @@
+// Package resource описывает идентичность сервиса (OTel resource), общую для трейсов и логов.
+package resource
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+var (
+	cgroupPath    = "/proc/self/cgroup"
+	mountinfoPath = "/proc/self/mountinfo"
+
+	containerIDRe = regexp.MustCompile(`[0-9a-f]{64}`)
+)
+
+// Переменные окружения, которые обычно заполняются через Kubernetes downward API.
+// Для каждого атрибута используется первая заданная переменная.
+var kubernetesEnv = []struct {
+	key  attribute.Key
+	envs []string
+}{
+	{semconv.K8SPodNameKey, []string{"K8S_POD_NAME", "POD_NAME"}},
+	{semconv.K8SPodUIDKey, []string{"K8S_POD_UID", "POD_UID"}},
+	{semconv.K8SNamespaceNameKey, []string{"K8S_NAMESPACE_NAME", "POD_NAMESPACE"}},
+	{semconv.K8SNodeNameKey, []string{"K8S_NODE_NAME", "NODE_NAME"}},
+}
+
+type config struct {
+	version     string
+	instanceID  string
+	environment string
+	attrs       []attribute.KeyValue
+	noDetect    bool
+}
+
+// Option определяет функцию для установки параметров resource.
+type Option func(*config)
+
+// WithServiceVersion устанавливает service.version.
+func WithServiceVersion(version string) Option {
+	return func(c *config) {
+		c.version = version
+	}
+}
+
+// WithInstanceID устанавливает service.instance.id. По умолчанию используется имя пода или хоста.
+func WithInstanceID(id string) Option {
+	return func(c *config) {
+		c.instanceID = id
+	}
+}
+
+// WithEnvironment устанавливает deployment.environment.
+func WithEnvironment(env string) Option {
+	return func(c *config) {
+		c.environment = env
+	}
+}
+
+// WithAttributes добавляет произвольные атрибуты. Они перекрывают найденные автоматически.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithoutDetectors отключает определение Kubernetes, контейнера и хоста.
+func WithoutDetectors() Option {
+	return func(c *config) {
+		c.noDetect = true
+	}
+}
+
+// New собирает resource сервиса serviceName.
+func New(ctx context.Context, serviceName string, opts ...Option) (*sdkresource.Resource, error) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceName(serviceName)}
+	if c.version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(c.version))
+	}
+	if c.environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(c.environment))
+	}
+
+	detectorOpts := []sdkresource.Option{sdkresource.WithTelemetrySDK()}
+	if !c.noDetect {
+		attrs = append(attrs, kubernetesAttrs()...)
+		if id := containerID(); id != "" {
+			attrs = append(attrs, semconv.ContainerID(id))
+		}
+		detectorOpts = append(detectorOpts, sdkresource.WithHost())
+	}
+
+	instanceID := c.instanceID
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+	if instanceID != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(instanceID))
+	}
+	attrs = append(attrs, c.attrs...)
+
+	res, err := sdkresource.New(ctx, append(detectorOpts, sdkresource.WithAttributes(attrs...))...)
+	if err != nil {
+		return nil, fmt.Errorf("resource.New: %w", err)
+	}
+	return res, nil
+}
+
+// LogAttrs возвращает атрибуты resource для логов без служебных telemetry.sdk.*.
+func LogAttrs(res *sdkresource.Resource) []slog.Attr {
+	if res == nil {
+		return nil
+	}
+	attrs := make([]slog.Attr, 0, res.Len())
+	for _, kv := range res.Attributes() {
+		if strings.HasPrefix(string(kv.Key), "telemetry.sdk.") {
+			continue
+		}
+		attrs = append(attrs, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	return attrs
+}
+
+func kubernetesAttrs() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, k := range kubernetesEnv {
+		for _, env := range k.envs {
+			if v := os.Getenv(env); v != "" {
+				attrs = append(attrs, k.key.String(v))
+				break
+			}
+		}
+	}
+	return attrs
+}
+
+func defaultInstanceID() string {
+	for _, env := range []string{"K8S_POD_NAME", "POD_NAME"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// containerID ищет идентификатор контейнера в cgroup (v1), а затем в mountinfo (v2)
+func containerID() string {
+	if id := findContainerID(cgroupPath, nil); id != "" {
+		return id
+	}
+	// при cgroup v2 идентификатор виден только в путях смонтированных файлов контейнера
+	return findContainerID(mountinfoPath, func(line string) bool {
+		return strings.Contains(line, "/containers/") || strings.Contains(line, "/sandboxes/")
+	})
+}
+
+func findContainerID(path string, match func(line string) bool) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match != nil && !match(line) {
+			continue
+		}
+		if id := containerIDRe.FindString(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package resource
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const testContainerID = "3f4e5d6c7b8a9f0e1d2c3b4a5f6e7d8c9b0a1f2e3d4c5b6a7f8e9d0c1b2a3f4e"
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("K8S_NAMESPACE_NAME", "payments")
+	t.Setenv("NODE_NAME", "node-1")
+
+	prevCgroup := cgroupPath
+	cgroupPath = writeFile(t, "12:memory:/kubepods/burstable/pod1/"+testContainerID+"\n")
+	defer func() { cgroupPath = prevCgroup }()
+
+	res, err := New(context.Background(), "api",
+		WithServiceVersion("1.2.3"),
+		WithEnvironment("prod"),
+		WithAttributes(attribute.String("team", "core"), semconv.K8SNodeName("override")),
+	)
+	require.NoError(t, err)
+
+	attrs := res.Set()
+	for key, expected := range map[attribute.Key]string{
+		semconv.ServiceNameKey:           "api",
+		semconv.ServiceVersionKey:        "1.2.3",
+		semconv.ServiceInstanceIDKey:     "api-7d9f",
+		semconv.DeploymentEnvironmentKey: "prod",
+		semconv.K8SPodNameKey:            "api-7d9f",
+		semconv.K8SNamespaceNameKey:      "payments",
+		semconv.K8SNodeNameKey:           "override",
+		semconv.ContainerIDKey:           testContainerID,
+		"team":                           "core",
+	} {
+		v, ok := attrs.Value(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, v.Emit(), key)
+	}
+}
+
+func TestContainerIDFromMountinfo(t *testing.T) {
+	prevCgroup, prevMountinfo := cgroupPath, mountinfoPath
+	defer func() { cgroupPath, mountinfoPath = prevCgroup, prevMountinfo }()
+
+	cgroupPath = writeFile(t, "0::/\n")
+	mountinfoPath = writeFile(t, strings.Join([]string{
+		"1 0 0:1 / / rw - overlay overlay rw,lowerdir=/var/lib/" + strings.Repeat("a", 64),
+		"2 1 0:2 /var/lib/docker/containers/" + testContainerID + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw",
+	}, "\n"))
+
+	assert.Equal(t, testContainerID, containerID())
+}
+
+func TestLogAttrs(t *testing.T) {
+	res, err := New(context.Background(), "api", WithoutDetectors(), WithInstanceID("i-1"))
+	require.NoError(t, err)
+
+	attrs := map[string]slog.Value{}
+	for _, attr := range LogAttrs(res) {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "api", attrs["service.name"].String())
+	assert.Equal(t, "i-1", attrs["service.instance.id"].String())
+	for key := range attrs {
+		assert.False(t, strings.HasPrefix(key, "telemetry.sdk."), key)
+	}
+	assert.Nil(t, LogAttrs(nil))
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"log/slog"
+
+	"github.com/MikL9/observability/hide"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Attributes преобразует slog атрибуты в атрибуты OTel. Строковые значения маскируются
+// через hide, группы разворачиваются в ключи вида "group.key".
+func Attributes(attrs ...slog.Attr) []attribute.KeyValue {
+	result := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		result = appendAttribute(result, "", attr)
+	}
+	return result
+}
+
+func appendAttribute(result []attribute.KeyValue, prefix string, attr slog.Attr) []attribute.KeyValue {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return result
+	}
+	key := attr.Key
+	if prefix != "" {
+		key = prefix + "." + attr.Key
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindBool:
+		return append(result, attribute.Bool(key, attr.Value.Bool()))
+	case slog.KindInt64:
+		return append(result, attribute.Int64(key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(result, attribute.Int64(key, int64(attr.Value.Uint64())))
+	case slog.KindFloat64:
+		return append(result, attribute.Float64(key, attr.Value.Float64()))
+	case slog.KindGroup:
+		for _, nested := range attr.Value.Group() {
+			result = appendAttribute(result, key, nested)
+		}
+		return result
+	default:
+		return append(result, attribute.String(key, hide.Hide(attr.Key, attr.Value.String())))
+	}
+}
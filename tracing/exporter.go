@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MikL9/observability/otlpconfig"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// Protocol протокол отправки трейсов в коллектор
+type Protocol = otlpconfig.Protocol
+
+const (
+	ProtocolGRPC = otlpconfig.ProtocolGRPC
+	ProtocolHTTP = otlpconfig.ProtocolHTTP
+)
+
+// Compression сжатие данных при отправке в коллектор
+type Compression = otlpconfig.Compression
+
+const (
+	CompressionNone = otlpconfig.CompressionNone
+	CompressionGzip = otlpconfig.CompressionGzip
+)
+
+// newExporter выбирает экспортер: stdout, если задан writer, иначе OTLP по protocol.
+// При пустом endpoint и без writer возвращает nil, трейсы при этом не отправляются
+func newExporter(ctx context.Context, endpoint string, opts *traceProviderOptions) (sdktrace.SpanExporter, error) {
+	if opts.writer != nil {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(opts.writer), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("stdouttrace.New: %w", err)
+		}
+		return exporter, nil
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.protocol {
+	case ProtocolHTTP:
+		return newHTTPExporter(ctx, endpoint, tlsConfig, opts)
+	case ProtocolGRPC, "":
+		return newGRPCExporter(ctx, endpoint, tlsConfig, opts)
+	default:
+		return nil, fmt.Errorf("tracing: unknown protocol %q", opts.protocol)
+	}
+}
+
+// newGRPCExporter создает экспортер со своим соединением, оно закрывается при TracerProvider.Shutdown
+func newGRPCExporter(ctx context.Context, endpoint string, tlsConfig *tls.Config, opts *traceProviderOptions) (sdktrace.SpanExporter, error) {
+	var exporterOpts []otlptracegrpc.Option
+	if strings.Contains(endpoint, "://") {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpointURL(endpoint))
+	} else {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(endpoint))
+	}
+	if tlsConfig != nil {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else if !strings.HasPrefix(endpoint, "https://") {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	if len(opts.headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithHeaders(opts.headers))
+	}
+	if opts.compression == CompressionGzip {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithCompressor(string(CompressionGzip)))
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("otlptracegrpc.New: %w", err)
+	}
+	return exporter, nil
+}
+
+func newHTTPExporter(ctx context.Context, endpoint string, tlsConfig *tls.Config, opts *traceProviderOptions) (sdktrace.SpanExporter, error) {
+	var exporterOpts []otlptracehttp.Option
+	if strings.Contains(endpoint, "://") {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(endpoint))
+	} else {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if tlsConfig != nil {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	} else if !strings.HasPrefix(endpoint, "https://") {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	if len(opts.headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.headers))
+	}
+	if opts.compression == CompressionGzip {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("otlptracehttp.New: %w", err)
+	}
+	return exporter, nil
+}
+
+// tlsConfig собирает настройки TLS из WithTLS и WithTLSFiles. nil означает незащищенное соединение
+func (o *traceProviderOptions) tlsConfig() (*tls.Config, error) {
+	if o.tls == nil && o.caFile == "" && o.certFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.tls != nil {
+		cfg = o.tls.Clone()
+	}
+	if o.caFile != "" {
+		ca, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("tracing: ca file %s contains no certificates", o.caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
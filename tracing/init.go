@@ -2,19 +2,13 @@ package tracing
 
 import (
 	"context"
-	"fmt"
 	"sync"
 	"time"
 
+	"github.com/MikL9/observability/logger/errors"
+	"github.com/MikL9/observability/resource"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
@@ -23,67 +17,95 @@ const (
 	batchTimeout       = 100 * time.Millisecond
 )
 
-var once sync.Once
+// ErrAlreadyInitialized возвращается при повторном Init с WithStrictGlobal
+var ErrAlreadyInitialized = errors.NewConstError("tracing: global tracer provider is already initialized")
 
-// Init initiate global provider for the project
-func Init(ctx context.Context, serviceName, jaegerEndpoint string, options ...TraceProviderOption) (*sdktrace.TracerProvider, error) {
-	conn, err := grpc.DialContext(ctx, jaegerEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("obesrvability.Init: grpc.DialContext: %w", err)
-	}
-
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("otlptracegrpc.New: %w", err)
-	}
+var (
+	globalMu    sync.Mutex
+	initialized bool
+)
 
+// Init initiate global provider for the project. Exporter is selected by options:
+// OTLP over gRPC (default) or HTTP to jaegerEndpoint, or stdout with WithStdoutExporter.
+// With an empty jaegerEndpoint spans are not sampled and not exported.
+// The provider and propagator are registered globally unless WithoutGlobal is set. Repeated
+// calls replace them, the previous provider must be stopped with Shutdown by the caller.
+// With WithStrictGlobal a repeated call returns ErrAlreadyInitialized instead.
+func Init(ctx context.Context, serviceName, jaegerEndpoint string, options ...TraceProviderOption) (*sdktrace.TracerProvider, error) {
 	opts := &traceProviderOptions{
 		sampleRate:         sampleRate,
 		maxExportBatchSize: maxExportBatchSize,
 		batchTimeout:       batchTimeout,
+		global:             true,
 	}
 
 	for _, option := range options {
 		option(opts)
 	}
 
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter, sdktrace.WithMaxExportBatchSize(opts.maxExportBatchSize),
-		sdktrace.WithBatchTimeout(opts.batchTimeout))
-
-	attrs := []attribute.KeyValue{
-		semconv.ServiceNameKey.String(serviceName),
+	propagators := opts.propagators
+	if len(propagators) == 0 {
+		propagators = defaultPropagators
+	}
+	propagator, err := NewPropagator(propagators...)
+	if err != nil {
+		return nil, err
 	}
-	if opts.env != "" {
-		attrs = append(attrs, attribute.String("env", opts.env))
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	if opts.global && initialized && opts.strictGlobal {
+		return nil, ErrAlreadyInitialized
 	}
 
-	res, err := resource.New(
-		ctx,
-		resource.WithAttributes(attrs...),
-		resource.WithTelemetrySDK(),
-		resource.WithHost(),
-	)
+	traceExporter, err := newExporter(ctx, jaegerEndpoint, opts)
 	if err != nil {
-		return nil, fmt.Errorf("resource.New: %w", err)
+		return nil, err
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(opts.sampleRate)),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+	res := opts.resource
+	if res == nil {
+		resourceOpts := opts.resourceOptions
+		if opts.env != "" {
+			resourceOpts = append([]resource.Option{resource.WithEnvironment(opts.env)}, resourceOpts...)
+		}
+		res, err = resource.New(ctx, serviceName, resourceOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	once.Do(func() {
-		otel.SetTextMapPropagator(
-			propagation.NewCompositeTextMapPropagator(
-				propagation.TraceContext{},
-				propagation.Baggage{},
-			),
+	providerOpts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if traceExporter != nil {
+		providerOpts = append(providerOpts,
+			sdktrace.WithSampler(newSampler(opts)),
+			sdktrace.WithSpanProcessor(newSpanProcessor(traceExporter, opts)),
 		)
+	} else {
+		providerOpts = append(providerOpts, sdktrace.WithSampler(sdktrace.NeverSample()))
+	}
+	tp := sdktrace.NewTracerProvider(providerOpts...)
+
+	if opts.global {
+		otel.SetTextMapPropagator(propagator)
 		otel.SetTracerProvider(tp)
-	})
+		initialized = true
+	}
 
 	return tp, nil
 }
+
+// newSpanProcessor создает batch процессор, обернутый в TailSamplingProcessor при WithTailSampling
+func newSpanProcessor(exporter sdktrace.SpanExporter, opts *traceProviderOptions) sdktrace.SpanProcessor {
+	var processor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exporter,
+		sdktrace.WithMaxExportBatchSize(opts.maxExportBatchSize),
+		sdktrace.WithBatchTimeout(opts.batchTimeout))
+	if !opts.tailSamplingEnabled {
+		return processor
+	}
+	tailOpts := opts.tailSampling
+	if opts.sampleRateSet {
+		tailOpts = append([]TailSamplingOption{WithTailSampleRatio(opts.sampleRate)}, tailOpts...)
+	}
+	return NewTailSamplingProcessor(processor, tailOpts...)
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+func TestInitStdoutExporter(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+
+	tp, err := Init(ctx, "test", "", WithStdoutExporter(&buf), WithoutGlobal())
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(ctx, "stdout_span")
+	assert.True(t, span.IsRecording())
+	span.End()
+	require.NoError(t, tp.Shutdown(ctx))
+
+	assert.Contains(t, buf.String(), `"Name": "stdout_span"`)
+}
+
+func TestInitNoopWithoutEndpoint(t *testing.T) {
+	ctx := context.Background()
+
+	tp, err := Init(ctx, "test", "", WithoutGlobal())
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(ctx, "noop_span")
+	assert.False(t, span.IsRecording())
+	assert.True(t, span.SpanContext().TraceID().IsValid())
+	span.End()
+	require.NoError(t, tp.Shutdown(ctx))
+}
+
+func TestInitHTTPExporter(t *testing.T) {
+	tp, err := Init(context.Background(), "test", "http://localhost:4318",
+		WithProtocol(ProtocolHTTP),
+		WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+		WithCompression(CompressionGzip),
+		WithoutGlobal(),
+	)
+	require.NoError(t, err)
+	assert.NotNil(t, tp)
+}
+
+func TestInitTLSFiles(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	_, err := Init(context.Background(), "test", "localhost:4317", WithTLSFiles(caFile, "", ""), WithoutGlobal())
+	assert.ErrorContains(t, err, "contains no certificates")
+
+	_, err = Init(context.Background(), "test", "localhost:4317", WithProtocol("udp"), WithoutGlobal())
+	assert.ErrorContains(t, err, "unknown protocol")
+}
+
+// encodingHandler сохраняет сжатие входящих запросов
+type encodingHandler struct {
+	encodings chan string
+}
+
+func (h *encodingHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *encodingHandler) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if in, ok := s.(*stats.InHeader); ok {
+		h.encodings <- in.Compression
+	}
+}
+
+func (h *encodingHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *encodingHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+type traceCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+}
+
+func (traceCollector) Export(context.Context, *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestInitGRPCExporterGzip(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	handler := &encodingHandler{encodings: make(chan string, 1)}
+	srv := grpc.NewServer(grpc.StatsHandler(handler))
+	coltracepb.RegisterTraceServiceServer(srv, traceCollector{})
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+
+	ctx := context.Background()
+	tp, err := Init(ctx, "test", lis.Addr().String(), WithCompression(CompressionGzip), WithoutGlobal())
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(ctx, "grpc_span")
+	span.End()
+	require.NoError(t, tp.Shutdown(ctx))
+
+	assert.Equal(t, "gzip", <-handler.encodings)
+}
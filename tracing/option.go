@@ -1,6 +1,16 @@
 package tracing
 
-import "time"
+import (
+	"crypto/tls"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MikL9/observability/metrics"
+	"github.com/MikL9/observability/resource"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
 
 type traceProviderOptions struct {
 	env string
@@ -8,12 +18,37 @@ type traceProviderOptions struct {
 	// Значение больше или равное 1 выберет все трассировки,
 	// значение 0.5 выберет 50% трассировок, а значение 0 отключит выборку.
 	sampleRate float64
+	// sampleRateSet показывает, что sampleRate задан через WithSampleRate.
+	sampleRateSet bool
 	// maxExportBatchSize определяет максимальный размер батча для экспорта трассировок.
 	maxExportBatchSize int
 	// batchTimeout определяет максимальное время ожидания перед отправкой данных (пакета) экспортеру трассировок.
 	// Если прошло более batchTimeout с момента последней отправки данных, текущий пакет будет немедленно отправлен.
 	// Время указывается в миллисекундах.
 	batchTimeout time.Duration
+
+	protocol    Protocol
+	tls         *tls.Config
+	caFile      string
+	certFile    string
+	keyFile     string
+	headers     map[string]string
+	compression Compression
+	writer      io.Writer
+
+	sampler       sdktrace.Sampler
+	samplingRules []SamplingRule
+	ignoreParent  bool
+
+	tailSampling        []TailSamplingOption
+	tailSamplingEnabled bool
+
+	resource        *sdkresource.Resource
+	resourceOptions []resource.Option
+
+	propagators  []Propagator
+	global       bool
+	strictGlobal bool
 }
 
 // TraceProviderOption определяет функцию для установки параметров конфигурации трассировки.
@@ -23,6 +58,7 @@ type TraceProviderOption func(*traceProviderOptions)
 func WithSampleRate(sampleRate float64) TraceProviderOption {
 	return func(opts *traceProviderOptions) {
 		opts.sampleRate = sampleRate
+		opts.sampleRateSet = true
 	}
 }
 
@@ -40,9 +76,174 @@ func WithBatchTimeout(batchTimeout time.Duration) TraceProviderOption {
 	}
 }
 
-// WithEnvAttribute добавляет к каждому трейсу атрибут env
+// WithEnvAttribute добавляет к каждому трейсу атрибут deployment.environment
 func WithEnvAttribute(env string) TraceProviderOption {
 	return func(opts *traceProviderOptions) {
 		opts.env = env
 	}
 }
+
+// WithProtocol устанавливает протокол отправки трейсов: ProtocolGRPC (по умолчанию) или ProtocolHTTP.
+func WithProtocol(protocol Protocol) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.protocol = protocol
+	}
+}
+
+// WithTLS включает TLS при подключении к коллектору. Для mTLS в config
+// должны быть заданы клиентские сертификаты.
+func WithTLS(config *tls.Config) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.tls = config
+	}
+}
+
+// WithTLSFiles включает TLS с корневым сертификатом caFile и, если заданы certFile и keyFile,
+// клиентским сертификатом для mTLS. Файлы читаются в Init.
+func WithTLSFiles(caFile, certFile, keyFile string) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.caFile = caFile
+		opts.certFile = certFile
+		opts.keyFile = keyFile
+	}
+}
+
+// WithHeaders добавляет заголовки к каждому запросу в коллектор, например токен авторизации.
+func WithHeaders(headers map[string]string) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.headers = headers
+	}
+}
+
+// WithCompression устанавливает сжатие данных при отправке в коллектор.
+func WithCompression(compression Compression) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.compression = compression
+	}
+}
+
+// WithStdoutExporter выводит трейсы в w вместо отправки в коллектор, например для локальной отладки.
+// Если w равен nil, используется os.Stdout.
+func WithStdoutExporter(w io.Writer) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		if w == nil {
+			w = os.Stdout
+		}
+		opts.writer = w
+	}
+}
+
+// WithSampler устанавливает корневой сэмплер вместо доли WithSampleRate, например
+// NewRateLimitingSampler или NewDynamicSampler для изменения доли во время работы.
+func WithSampler(sampler sdktrace.Sampler) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.sampler = sampler
+	}
+}
+
+// WithSamplingRules добавляет правила, которые проверяются до корневого сэмплера.
+func WithSamplingRules(rules ...SamplingRule) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.samplingRules = append(opts.samplingRules, rules...)
+	}
+}
+
+// WithoutParentBased отключает наследование решения о сэмплировании от родительского спана.
+func WithoutParentBased() TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.ignoreParent = true
+	}
+}
+
+// WithTailSampling включает tail sampling: head sampler сэмплирует все трейсы, а решение
+// о сохранении принимает TailSamplingProcessor. Доля WithSampleRate, если она задана, применяется
+// к трейсам без ошибок и медленных спанов вместо доли TailSamplingProcessor по умолчанию.
+func WithTailSampling(options ...TailSamplingOption) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.tailSampling = append(opts.tailSampling, options...)
+		opts.tailSamplingEnabled = true
+	}
+}
+
+// TailSamplingOption определяет функцию для установки параметров TailSamplingProcessor.
+type TailSamplingOption func(*TailSamplingProcessor)
+
+// WithDecisionWait устанавливает максимальное время ожидания спанов трейса, по умолчанию 5 секунд.
+func WithDecisionWait(wait time.Duration) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.DecisionWait = wait
+	}
+}
+
+// WithLatencyThreshold сохраняет трейсы со спанами дольше threshold.
+func WithLatencyThreshold(threshold time.Duration) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.LatencyThreshold = threshold
+	}
+}
+
+// WithTailSampleRatio устанавливает долю сохраняемых трейсов без ошибок и медленных спанов.
+func WithTailSampleRatio(ratio float64) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.SampleRatio = ratio
+	}
+}
+
+// WithMaxSpansPerTrace ограничивает число буферизуемых спанов одного трейса, по умолчанию 1000.
+func WithMaxSpansPerTrace(n int) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.MaxSpansPerTrace = n
+	}
+}
+
+// WithMaxTraces ограничивает число трейсов в буфере, по умолчанию 10000.
+func WithMaxTraces(n int) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.MaxTraces = n
+	}
+}
+
+// WithTailMetrics включает метрики tail sampling, например через observability.GetMetrics().
+func WithTailMetrics(provider metrics.Provider, serviceID string) TailSamplingOption {
+	return func(p *TailSamplingProcessor) {
+		p.metrics = newTailMetrics(provider, serviceID)
+	}
+}
+
+// WithResource устанавливает готовый resource, например общий с логгером.
+// Опции WithEnvAttribute и WithResourceOptions при этом не применяются.
+func WithResource(res *sdkresource.Resource) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.resource = res
+	}
+}
+
+// WithResourceOptions добавляет параметры resource: версию, instance id и произвольные атрибуты.
+func WithResourceOptions(options ...resource.Option) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.resourceOptions = append(opts.resourceOptions, options...)
+	}
+}
+
+// WithPropagators устанавливает форматы передачи контекста трейса.
+// По умолчанию используются PropagatorTraceContext и PropagatorBaggage.
+func WithPropagators(propagators ...Propagator) TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.propagators = propagators
+	}
+}
+
+// WithStrictGlobal запрещает повторный Init: если глобальные provider и propagator уже
+// зарегистрированы, Init возвращает ErrAlreadyInitialized вместо их замены.
+func WithStrictGlobal() TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.strictGlobal = true
+	}
+}
+
+// WithoutGlobal создает provider без регистрации глобальных provider и propagator.
+func WithoutGlobal() TraceProviderOption {
+	return func(opts *traceProviderOptions) {
+		opts.global = false
+	}
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator формат заголовков для передачи контекста трейса между сервисами
+type Propagator string
+
+const (
+	// PropagatorTraceContext заголовки W3C traceparent и tracestate
+	PropagatorTraceContext Propagator = "tracecontext"
+	// PropagatorBaggage заголовок W3C baggage
+	PropagatorBaggage Propagator = "baggage"
+	// PropagatorB3 одиночный заголовок b3
+	PropagatorB3 Propagator = "b3"
+	// PropagatorB3Multi заголовки X-B3-TraceId, X-B3-SpanId и X-B3-Sampled
+	PropagatorB3Multi Propagator = "b3multi"
+	// PropagatorJaeger заголовок uber-trace-id
+	PropagatorJaeger Propagator = "jaeger"
+)
+
+var defaultPropagators = []Propagator{PropagatorTraceContext, PropagatorBaggage}
+
+// NewPropagator собирает составной propagator. При извлечении контекста
+// используется последний формат из propagators, заголовки которого есть в запросе.
+func NewPropagator(propagators ...Propagator) (propagation.TextMapPropagator, error) {
+	list := make([]propagation.TextMapPropagator, 0, len(propagators))
+	for _, p := range propagators {
+		switch p {
+		case PropagatorTraceContext:
+			list = append(list, propagation.TraceContext{})
+		case PropagatorBaggage:
+			list = append(list, propagation.Baggage{})
+		case PropagatorB3:
+			list = append(list, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			list = append(list, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorJaeger:
+			list = append(list, jaeger.Jaeger{})
+		default:
+			return nil, fmt.Errorf("tracing: unknown propagator %q", p)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(list...), nil
+}
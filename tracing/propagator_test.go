@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewPropagator(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c},
+		SpanID:     trace.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	testCases := []struct {
+		propagator Propagator
+		header     string
+	}{
+		{PropagatorTraceContext, "Traceparent"},
+		{PropagatorB3, "B3"},
+		{PropagatorB3Multi, "X-B3-Traceid"},
+		{PropagatorJaeger, "Uber-Trace-Id"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.propagator), func(t *testing.T) {
+			p, err := NewPropagator(tc.propagator)
+			require.NoError(t, err)
+
+			header := http.Header{}
+			p.Inject(ctx, propagation.HeaderCarrier(header))
+			assert.NotEmpty(t, header.Get(tc.header))
+
+			extracted := trace.SpanContextFromContext(p.Extract(context.Background(), propagation.HeaderCarrier(header)))
+			assert.Equal(t, sc.TraceID(), extracted.TraceID())
+			assert.Equal(t, sc.SpanID(), extracted.SpanID())
+		})
+	}
+
+	_, err := NewPropagator("xray")
+	assert.ErrorContains(t, err, "unknown propagator")
+}
+
+func TestInitGlobal(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		initialized = false
+	}()
+	ctx := context.Background()
+
+	tp, err := Init(ctx, "test", "", WithPropagators(PropagatorJaeger))
+	require.NoError(t, err)
+	assert.Same(t, tp, otel.GetTracerProvider())
+	assert.Equal(t, []string{"uber-trace-id"}, otel.GetTextMapPropagator().Fields())
+
+	_, err = Init(ctx, "test", "", WithStrictGlobal())
+	assert.ErrorIs(t, err, ErrAlreadyInitialized)
+	assert.Same(t, tp, otel.GetTracerProvider())
+
+	replaced, err := Init(ctx, "test", "", WithPropagators(PropagatorB3))
+	require.NoError(t, err)
+	assert.Same(t, replaced, otel.GetTracerProvider())
+	assert.Equal(t, []string{"b3"}, otel.GetTextMapPropagator().Fields())
+}
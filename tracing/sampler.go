@@ -0,0 +1,181 @@
+package tracing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// SamplingRule задает сэмплер для спанов, подходящих под Match. Решение принимается
+// при старте спана, поэтому правила видят только имя и атрибуты, заданные при создании.
+// Ошибки, возникшие позже, учитываются только tail sampling.
+type SamplingRule struct {
+	Match   func(p sdktrace.SamplingParameters) bool
+	Sampler sdktrace.Sampler
+}
+
+// SpanNameRule применяет sampler к спанам с именем pattern. Pattern, оканчивающийся на "*",
+// сравнивается как префикс.
+func SpanNameRule(pattern string, sampler sdktrace.Sampler) SamplingRule {
+	return SamplingRule{
+		Match: func(p sdktrace.SamplingParameters) bool {
+			return matchPattern(pattern, p.Name)
+		},
+		Sampler: sampler,
+	}
+}
+
+// AttributeRule применяет sampler к спанам, у которых при старте атрибут key совпадает с pattern.
+func AttributeRule(key attribute.Key, pattern string, sampler sdktrace.Sampler) SamplingRule {
+	return SamplingRule{
+		Match: func(p sdktrace.SamplingParameters) bool {
+			for _, attr := range p.Attributes {
+				if attr.Key == key && matchPattern(pattern, attr.Value.Emit()) {
+					return true
+				}
+			}
+			return false
+		},
+		Sampler: sampler,
+	}
+}
+
+// RouteRule применяет sampler к серверным спанам с маршрутом или путем route, например "/health".
+func RouteRule(route string, sampler sdktrace.Sampler) SamplingRule {
+	byRoute := AttributeRule(semconv.HTTPRouteKey, route, sampler).Match
+	byPath := AttributeRule(semconv.URLPathKey, route, sampler).Match
+	byTarget := AttributeRule(semconv.HTTPTargetKey, route, sampler).Match
+	return SamplingRule{
+		Match: func(p sdktrace.SamplingParameters) bool {
+			// имена серверных спанов имеют вид "GET /health"
+			name := p.Name
+			if _, path, ok := strings.Cut(p.Name, " "); ok {
+				name = path
+			}
+			return byRoute(p) || byPath(p) || byTarget(p) || matchPattern(route, name)
+		},
+		Sampler: sampler,
+	}
+}
+
+func matchPattern(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
+
+type ruleBasedSampler struct {
+	rules    []SamplingRule
+	fallback sdktrace.Sampler
+}
+
+// NewRuleBasedSampler применяет сэмплер первого подходящего правила, иначе fallback.
+func NewRuleBasedSampler(fallback sdktrace.Sampler, rules ...SamplingRule) sdktrace.Sampler {
+	return &ruleBasedSampler{rules: rules, fallback: fallback}
+}
+
+func (s *ruleBasedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, rule := range s.rules {
+		if rule.Match(p) {
+			return rule.Sampler.ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s *ruleBasedSampler) Description() string {
+	return fmt.Sprintf("RuleBased{rules:%d,fallback:%s}", len(s.rules), s.fallback.Description())
+}
+
+type rateLimitingSampler struct {
+	perSecond float64
+
+	mu       sync.Mutex
+	balance  float64
+	lastTick time.Time
+}
+
+// NewRateLimitingSampler сэмплирует не больше perSecond трейсов в секунду.
+func NewRateLimitingSampler(perSecond float64) sdktrace.Sampler {
+	return &rateLimitingSampler{
+		perSecond: perSecond,
+		balance:   math.Max(perSecond, 1),
+		lastTick:  timeNow(),
+	}
+}
+
+var timeNow = time.Now
+
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	s.mu.Lock()
+	now := timeNow()
+	s.balance = math.Min(s.balance+now.Sub(s.lastTick).Seconds()*s.perSecond, math.Max(s.perSecond, 1))
+	s.lastTick = now
+	sampled := s.balance >= 1
+	if sampled {
+		s.balance--
+	}
+	s.mu.Unlock()
+
+	if sampled {
+		return sdktrace.AlwaysSample().ShouldSample(p)
+	}
+	return sdktrace.NeverSample().ShouldSample(p)
+}
+
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimiting{%g}", s.perSecond)
+}
+
+// DynamicSampler сэмплирует долю трейсов, которую можно менять во время работы через SetSampleRate.
+type DynamicSampler struct {
+	sampler atomic.Pointer[sdktrace.Sampler]
+}
+
+func NewDynamicSampler(rate float64) *DynamicSampler {
+	s := &DynamicSampler{}
+	s.SetSampleRate(rate)
+	return s
+}
+
+// SetSampleRate устанавливает долю сэмплируемых трейсов от 0 до 1.
+func (s *DynamicSampler) SetSampleRate(rate float64) {
+	sampler := sdktrace.TraceIDRatioBased(rate)
+	s.sampler.Store(&sampler)
+}
+
+func (s *DynamicSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return (*s.sampler.Load()).ShouldSample(p)
+}
+
+func (s *DynamicSampler) Description() string {
+	return fmt.Sprintf("Dynamic{%s}", (*s.sampler.Load()).Description())
+}
+
+// newSampler собирает сэмплер из опций: корневой сэмплер, правила и ParentBased поверх них.
+func newSampler(opts *traceProviderOptions) sdktrace.Sampler {
+	sampler := opts.sampler
+	switch {
+	case sampler != nil:
+	case opts.tailSamplingEnabled:
+		// доля применяется в TailSamplingProcessor
+		sampler = sdktrace.AlwaysSample()
+	default:
+		sampler = sdktrace.TraceIDRatioBased(opts.sampleRate)
+	}
+	if len(opts.samplingRules) > 0 {
+		sampler = NewRuleBasedSampler(sampler, opts.samplingRules...)
+	}
+	if opts.ignoreParent {
+		return sampler
+	}
+	return sdktrace.ParentBased(sampler)
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func samplingParams(name string, attrs ...attribute.KeyValue) sdktrace.SamplingParameters {
+	return sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1},
+		Name:          name,
+		Attributes:    attrs,
+	}
+}
+
+func isSampled(s sdktrace.Sampler, p sdktrace.SamplingParameters) bool {
+	return s.ShouldSample(p).Decision == sdktrace.RecordAndSample
+}
+
+func TestRuleBasedSampler(t *testing.T) {
+	s := NewRuleBasedSampler(sdktrace.AlwaysSample(),
+		RouteRule("/health", sdktrace.NeverSample()),
+		SpanNameRule("payment_*", sdktrace.AlwaysSample()),
+		AttributeRule("tenant", "internal", sdktrace.NeverSample()),
+	)
+
+	assert.False(t, isSampled(s, samplingParams("GET /health")))
+	assert.False(t, isSampled(s, samplingParams("handler", semconv.HTTPRoute("/health"))))
+	assert.False(t, isSampled(s, samplingParams("handler", attribute.String("tenant", "internal"))))
+	assert.True(t, isSampled(s, samplingParams("GET /healthz")))
+	assert.True(t, isSampled(s, samplingParams("payment_create", attribute.String("tenant", "internal"))))
+	assert.True(t, isSampled(s, samplingParams("GET /users")))
+}
+
+func TestParentBasedByDefault(t *testing.T) {
+	s := newSampler(&traceProviderOptions{sampleRate: 0})
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	p := samplingParams("child")
+	p.ParentContext = trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	assert.True(t, isSampled(s, p))
+	assert.False(t, isSampled(s, samplingParams("root")))
+
+	s = newSampler(&traceProviderOptions{sampleRate: 0, ignoreParent: true})
+	assert.False(t, isSampled(s, p))
+}
+
+func TestRateLimitingSampler(t *testing.T) {
+	now := time.Unix(0, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	s := NewRateLimitingSampler(2)
+	p := samplingParams("span")
+	assert.True(t, isSampled(s, p))
+	assert.True(t, isSampled(s, p))
+	assert.False(t, isSampled(s, p))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, isSampled(s, p))
+	assert.False(t, isSampled(s, p))
+}
+
+func TestDynamicSampler(t *testing.T) {
+	s := NewDynamicSampler(0)
+	p := samplingParams("span")
+	assert.False(t, isSampled(s, p))
+
+	s.SetSampleRate(1)
+	assert.True(t, isSampled(s, p))
+	assert.Equal(t, "Dynamic{AlwaysOnSampler}", s.Description())
+}
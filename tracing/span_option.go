@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanConfig struct {
+	kind  trace.SpanKind
+	links []trace.Link
+	attrs []slog.Attr
+}
+
+// SpanOption определяет функцию для установки параметров создаваемого спана.
+type SpanOption func(*spanConfig)
+
+// WithSpanKind устанавливает тип спана, например trace.SpanKindConsumer для обработчиков очередей.
+// По умолчанию создается trace.SpanKindInternal.
+func WithSpanKind(kind trace.SpanKind) SpanOption {
+	return func(c *spanConfig) {
+		c.kind = kind
+	}
+}
+
+// WithLinks связывает спан с другими трейсами, например с трейсами producer'ов
+// сообщений, обрабатываемых одним батчем.
+func WithLinks(links ...trace.Link) SpanOption {
+	return func(c *spanConfig) {
+		c.links = append(c.links, links...)
+	}
+}
+
+// WithLinkFromContext связывает спан со спаном из ctx, например извлеченным
+// из заголовков сообщения через propagator. Контекст без спана игнорируется.
+func WithLinkFromContext(ctx context.Context, attrs ...slog.Attr) SpanOption {
+	return func(c *spanConfig) {
+		link := trace.LinkFromContext(ctx, Attributes(attrs...)...)
+		if link.SpanContext.IsValid() {
+			c.links = append(c.links, link)
+		}
+	}
+}
+
+// WithAttrs устанавливает атрибуты спана при создании, что позволяет сэмплерам учитывать их.
+func WithAttrs(attrs ...slog.Attr) SpanOption {
+	return func(c *spanConfig) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+func (c *spanConfig) startOptions() []trace.SpanStartOption {
+	var opts []trace.SpanStartOption
+	if c.kind != trace.SpanKindUnspecified {
+		opts = append(opts, trace.WithSpanKind(c.kind))
+	}
+	if len(c.links) > 0 {
+		opts = append(opts, trace.WithLinks(c.links...))
+	}
+	if len(c.attrs) > 0 {
+		opts = append(opts, trace.WithAttributes(Attributes(c.attrs...)...))
+	}
+	return opts
+}
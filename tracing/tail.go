@@ -0,0 +1,314 @@
+package tracing
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MikL9/observability/metrics"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	decisionKeep  = "keep"
+	decisionDrop  = "drop"
+	reasonError   = "error"
+	reasonLatency = "latency"
+	reasonRatio   = "ratio"
+)
+
+const (
+	defaultDecisionWait     = 5 * time.Second
+	defaultMaxSpansPerTrace = 1000
+	defaultMaxTraces        = 10000
+)
+
+// TailSamplingProcessor буферизует завершенные спаны трейса и после завершения локального
+// корневого спана или истечения DecisionWait решает, передавать ли трейс в next.
+// Сохраняются трейсы со статусом ошибки (его выставляет SpanWrapper.StopWrap),
+// трейсы со спаном дольше LatencyThreshold и доля SampleRatio остальных.
+// Для корректной работы head sampler должен сэмплировать все трейсы.
+type TailSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+
+	// DecisionWait максимальное время ожидания спанов трейса до принятия решения.
+	DecisionWait time.Duration
+	// LatencyThreshold длительность спана, начиная с которой трейс сохраняется. 0 отключает проверку.
+	LatencyThreshold time.Duration
+	// SampleRatio доля сохраняемых трейсов без ошибок и медленных спанов.
+	SampleRatio float64
+	// MaxSpansPerTrace ограничивает число буферизуемых спанов одного трейса, остальные отбрасываются.
+	MaxSpansPerTrace int
+	// MaxTraces ограничивает число трейсов в буфере, при превышении решение по самому старому принимается досрочно.
+	MaxTraces int
+
+	mu      sync.Mutex
+	traces  map[trace.TraceID]*tailTrace
+	decided map[trace.TraceID]tailDecision
+	ratio   sdktrace.Sampler
+	metrics *tailMetrics
+	// order трейсы буфера в порядке появления, первым самый старый
+	order *list.List
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type tailTrace struct {
+	id        trace.TraceID
+	elem      *list.Element
+	spans     []sdktrace.ReadOnlySpan
+	firstSeen time.Time
+	hasError  bool
+	slow      bool
+}
+
+type tailDecision struct {
+	keep bool
+	at   time.Time
+}
+
+// NewTailSamplingProcessor создает процессор, передающий сохраненные трейсы в next,
+// например в sdktrace.NewBatchSpanProcessor.
+func NewTailSamplingProcessor(next sdktrace.SpanProcessor, opts ...TailSamplingOption) *TailSamplingProcessor {
+	p := &TailSamplingProcessor{
+		next:             next,
+		DecisionWait:     defaultDecisionWait,
+		SampleRatio:      0.1,
+		MaxSpansPerTrace: defaultMaxSpansPerTrace,
+		MaxTraces:        defaultMaxTraces,
+		traces:           make(map[trace.TraceID]*tailTrace),
+		order:            list.New(),
+		decided:          make(map[trace.TraceID]tailDecision),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	// неположительные значения заменяются значениями по умолчанию,
+	// иначе буфер вытеснял бы каждый трейс или отбрасывал все спаны
+	if p.DecisionWait <= 0 {
+		p.DecisionWait = defaultDecisionWait
+	}
+	if p.MaxSpansPerTrace <= 0 {
+		p.MaxSpansPerTrace = defaultMaxSpansPerTrace
+	}
+	if p.MaxTraces <= 0 {
+		p.MaxTraces = defaultMaxTraces
+	}
+	p.ratio = sdktrace.TraceIDRatioBased(p.SampleRatio)
+
+	go p.loop()
+	return p
+}
+
+func (p *TailSamplingProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *TailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	traceID := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	if d, ok := p.decided[traceID]; ok {
+		p.mu.Unlock()
+		if d.keep {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	var spans []sdktrace.ReadOnlySpan
+	t, ok := p.traces[traceID]
+	if !ok {
+		if len(p.traces) >= p.MaxTraces {
+			spans = p.evictOldestLocked()
+		}
+		t = &tailTrace{id: traceID, firstSeen: timeNow()}
+		t.elem = p.order.PushBack(t)
+		p.traces[traceID] = t
+		p.metrics.addPending(1)
+	}
+	t.hasError = t.hasError || s.Status().Code == codes.Error
+	t.slow = t.slow || (p.LatencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.LatencyThreshold)
+	if len(t.spans) < p.MaxSpansPerTrace {
+		t.spans = append(t.spans, s)
+	} else {
+		p.metrics.droppedSpan()
+	}
+
+	if isLocalRoot(s) {
+		spans = append(spans, p.decideLocked(t)...)
+	}
+	p.mu.Unlock()
+
+	p.export(spans)
+}
+
+// decideLocked принимает решение по трейсу и возвращает спаны, которые нужно передать в next
+func (p *TailSamplingProcessor) decideLocked(t *tailTrace) []sdktrace.ReadOnlySpan {
+	traceID := t.id
+	delete(p.traces, traceID)
+	p.order.Remove(t.elem)
+	p.metrics.addPending(-1)
+
+	reason := reasonRatio
+	keep := true
+	switch {
+	case t.hasError:
+		reason = reasonError
+	case t.slow:
+		reason = reasonLatency
+	default:
+		keep = p.ratio.ShouldSample(sdktrace.SamplingParameters{TraceID: traceID}).Decision == sdktrace.RecordAndSample
+	}
+	p.decided[traceID] = tailDecision{keep: keep, at: timeNow()}
+	p.metrics.decision(keep, reason)
+
+	if !keep {
+		return nil
+	}
+	return t.spans
+}
+
+// evictOldestLocked принимает решение по самому старому трейсу и возвращает спаны для next
+func (p *TailSamplingProcessor) evictOldestLocked() []sdktrace.ReadOnlySpan {
+	front := p.order.Front()
+	if front == nil {
+		return nil
+	}
+	p.metrics.evicted()
+	return p.decideLocked(front.Value.(*tailTrace))
+}
+
+func (p *TailSamplingProcessor) export(spans []sdktrace.ReadOnlySpan) {
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
+
+func (p *TailSamplingProcessor) loop() {
+	defer close(p.done)
+	ticker := time.NewTicker(max(p.DecisionWait/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.flush(false)
+		}
+	}
+}
+
+// flush принимает решение по трейсам, ожидающим дольше DecisionWait, или по всем при all
+func (p *TailSamplingProcessor) flush(all bool) {
+	now := timeNow()
+	var spans []sdktrace.ReadOnlySpan
+
+	p.mu.Lock()
+	for e := p.order.Front(); e != nil; e = p.order.Front() {
+		t := e.Value.(*tailTrace)
+		if !all && now.Sub(t.firstSeen) < p.DecisionWait {
+			break
+		}
+		spans = append(spans, p.decideLocked(t)...)
+	}
+	for id, d := range p.decided {
+		if now.Sub(d.at) >= p.DecisionWait {
+			delete(p.decided, id)
+		}
+	}
+	p.mu.Unlock()
+
+	p.export(spans)
+}
+
+// ForceFlush принимает решение по всем буферизованным трейсам и сбрасывает next.
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.flush(true)
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+	p.flush(true)
+	return p.next.Shutdown(ctx)
+}
+
+func isLocalRoot(s sdktrace.ReadOnlySpan) bool {
+	return !s.Parent().IsValid() || s.Parent().IsRemote()
+}
+
+type tailMetrics struct {
+	tracesTotal        metrics.Counter
+	evictedTracesTotal metrics.Counter
+	droppedSpansTotal  metrics.Counter
+	pendingTraces      metrics.UpDownCounter
+}
+
+func newTailMetrics(provider metrics.Provider, serviceID string) *tailMetrics {
+	return &tailMetrics{
+		tracesTotal: provider.Counter(metrics.Opts{
+			Namespace: "tracing",
+			Subsystem: serviceID,
+			Name:      "tail_sampling_traces_total",
+			Help:      "Количество трейсов, по которым принято решение tail sampling",
+			Labels:    []string{"decision", "reason"},
+		}),
+		evictedTracesTotal: provider.Counter(metrics.Opts{
+			Namespace: "tracing",
+			Subsystem: serviceID,
+			Name:      "tail_sampling_evicted_traces_total",
+			Help:      "Количество трейсов, решение по которым принято досрочно из-за лимита буфера",
+		}),
+		droppedSpansTotal: provider.Counter(metrics.Opts{
+			Namespace: "tracing",
+			Subsystem: serviceID,
+			Name:      "tail_sampling_dropped_spans_total",
+			Help:      "Количество спанов, отброшенных из-за лимита спанов на трейс",
+		}),
+		pendingTraces: provider.UpDownCounter(metrics.Opts{
+			Namespace: "tracing",
+			Subsystem: serviceID,
+			Name:      "tail_sampling_pending_traces",
+			Help:      "Количество трейсов в буфере tail sampling",
+		}),
+	}
+}
+
+func (m *tailMetrics) decision(keep bool, reason string) {
+	if m == nil {
+		return
+	}
+	decision := decisionDrop
+	if keep {
+		decision = decisionKeep
+	}
+	m.tracesTotal.Add(context.Background(), 1, decision, reason)
+}
+
+func (m *tailMetrics) evicted() {
+	if m != nil {
+		m.evictedTracesTotal.Add(context.Background(), 1)
+	}
+}
+
+func (m *tailMetrics) droppedSpan() {
+	if m != nil {
+		m.droppedSpansTotal.Add(context.Background(), 1)
+	}
+}
+
+func (m *tailMetrics) addPending(v float64) {
+	if m != nil {
+		m.pendingTraces.Add(context.Background(), v)
+	}
+}
@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MikL9/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTailProvider(t *testing.T, opts ...TailSamplingOption) (trace.Tracer, *TailSamplingProcessor, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	processor := NewTailSamplingProcessor(recorder, append([]TailSamplingOption{WithTailSampleRatio(0)}, opts...)...)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp.Tracer("test"), processor, recorder
+}
+
+func spanNames(recorder *tracetest.SpanRecorder) []string {
+	var names []string
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestTailSamplingKeepsErrorAndSlowTraces(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	tracer, _, recorder := newTailProvider(t,
+		WithLatencyThreshold(time.Second),
+		WithTailMetrics(metrics.NewPrometheusProvider(reg), "test"),
+	)
+	ctx := context.Background()
+
+	rootCtx, root := tracer.Start(ctx, "error_root")
+	_, child := tracer.Start(rootCtx, "error_child")
+	child.SetStatus(codes.Error, errors.New("failed").Error())
+	child.End()
+	assert.Empty(t, recorder.Ended(), "spans are buffered until the root ends")
+	root.End()
+
+	start := time.Now()
+	_, slow := tracer.Start(ctx, "slow_root", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	rootCtx, root = tracer.Start(ctx, "fast_root")
+	_, child = tracer.Start(rootCtx, "fast_child")
+	child.End()
+	root.End()
+
+	assert.ElementsMatch(t, []string{"error_child", "error_root", "slow_root"}, spanNames(recorder))
+	const traces = "tracing_test_tail_sampling_traces_total"
+	assert.Equal(t, 1.0, gatherValue(t, reg, traces, decisionKeep, reasonError))
+	assert.Equal(t, 1.0, gatherValue(t, reg, traces, decisionKeep, reasonLatency))
+	assert.Equal(t, 1.0, gatherValue(t, reg, traces, decisionDrop, reasonRatio))
+}
+
+func TestTailSamplingLateSpansFollowDecision(t *testing.T) {
+	tracer, processor, recorder := newTailProvider(t)
+	ctx := context.Background()
+
+	rootCtx, root := tracer.Start(ctx, "root")
+	_, late := tracer.Start(rootCtx, "late_child")
+	root.SetStatus(codes.Error, "failed")
+	root.End()
+	late.End()
+
+	// дочерний спан без корня ожидает решения до DecisionWait или ForceFlush
+	_, orphanRoot := tracer.Start(ctx, "orphan_root")
+	orphanCtx := trace.ContextWithSpan(ctx, orphanRoot)
+	_, orphan := tracer.Start(orphanCtx, "orphan")
+	orphan.SetStatus(codes.Error, "failed")
+	orphan.End()
+	require.NoError(t, processor.ForceFlush(ctx))
+
+	assert.ElementsMatch(t, []string{"root", "late_child", "orphan"}, spanNames(recorder))
+}
+
+func TestTailSamplingLimits(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	tracer, _, recorder := newTailProvider(t,
+		WithMaxSpansPerTrace(2),
+		WithMaxTraces(1),
+		WithTailSampleRatio(1),
+		WithTailMetrics(metrics.NewPrometheusProvider(reg), "limits"),
+	)
+	ctx := context.Background()
+
+	rootCtx, root := tracer.Start(ctx, "root")
+	for range 3 {
+		_, child := tracer.Start(rootCtx, "child")
+		child.End()
+	}
+	assert.Equal(t, 1.0, gatherValue(t, reg, "tracing_limits_tail_sampling_dropped_spans_total"))
+
+	// второй трейс вытесняет первый из буфера
+	otherCtx, other := tracer.Start(ctx, "other_root")
+	_, otherChild := tracer.Start(otherCtx, "other_child")
+	otherChild.End()
+	assert.Equal(t, 1.0, gatherValue(t, reg, "tracing_limits_tail_sampling_evicted_traces_total"))
+	assert.Equal(t, []string{"child", "child"}, spanNames(recorder))
+
+	root.End()
+	other.End()
+	assert.Len(t, recorder.Ended(), 5)
+	assert.Zero(t, gatherValue(t, reg, "tracing_limits_tail_sampling_pending_traces"))
+}
+
+func TestTailSamplingRatioFromSampleRate(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	opts := &traceProviderOptions{sampleRate: sampleRate}
+	WithTailSampling()(opts)
+	processor := newSpanProcessor(exporter, opts).(*TailSamplingProcessor)
+	assert.Equal(t, 0.1, processor.SampleRatio)
+	require.NoError(t, processor.Shutdown(context.Background()))
+
+	WithSampleRate(0.5)(opts)
+	processor = newSpanProcessor(exporter, opts).(*TailSamplingProcessor)
+	assert.Equal(t, 0.5, processor.SampleRatio)
+	require.NoError(t, processor.Shutdown(context.Background()))
+}
+
+// gatherValue возвращает значение счетчика или gauge name со значениями меток labels
+func gatherValue(t *testing.T, reg *prometheus.Registry, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			values := make([]string, 0, len(m.GetLabel()))
+			for _, pair := range m.GetLabel() {
+				values = append(values, pair.GetValue())
+			}
+			if !slices.Equal(values, labels) {
+				continue
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestTailSamplingNonPositiveLimits(t *testing.T) {
+	tracer, processor, recorder := newTailProvider(t,
+		WithDecisionWait(0),
+		WithMaxSpansPerTrace(0),
+		WithMaxTraces(-1),
+		WithTailSampleRatio(1),
+	)
+	assert.Equal(t, defaultDecisionWait, processor.DecisionWait)
+	assert.Equal(t, defaultMaxSpansPerTrace, processor.MaxSpansPerTrace)
+	assert.Equal(t, defaultMaxTraces, processor.MaxTraces)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	assert.Empty(t, recorder.Ended())
+	root.End()
+	assert.Equal(t, []string{"child", "root"}, spanNames(recorder))
+}
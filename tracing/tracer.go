@@ -2,6 +2,7 @@ package tracing
 
 import (
 	"context"
+	"log/slog"
 	"strings"
 
 	"github.com/MikL9/observability/logger/errors"
@@ -14,22 +15,38 @@ import (
 type SpanWrapper struct {
 	ctx *context.Context
 	trace.Span
-	op string
+	op   string
+	opts []trace.SpanStartOption
 }
 
-func New(ctx *context.Context, op string) *SpanWrapper {
+func New(ctx *context.Context, op string, opts ...SpanOption) *SpanWrapper {
+	cfg := &spanConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return &SpanWrapper{
-		ctx: ctx,
-		op:  op,
+		ctx:  ctx,
+		op:   op,
+		opts: cfg.startOptions(),
 	}
 }
 
 func (s *SpanWrapper) Start() {
 	var ctx context.Context
-	ctx, s.Span = otel.Tracer(s.op).Start(*s.ctx, s.op)
+	ctx, s.Span = otel.Tracer(s.op).Start(*s.ctx, s.op, s.opts...)
 	*s.ctx = ctx
 }
 
+// SetAttrs добавляет к спану атрибуты, строковые значения маскируются через hide
+func (s *SpanWrapper) SetAttrs(attrs ...slog.Attr) {
+	s.SetAttributes(Attributes(attrs...)...)
+}
+
+// AddEventAttrs добавляет к спану событие name с атрибутами, строковые значения маскируются через hide
+func (s *SpanWrapper) AddEventAttrs(name string, attrs ...slog.Attr) {
+	s.AddEvent(name, trace.WithAttributes(Attributes(attrs...)...))
+}
+
 func (s *SpanWrapper) StopWrap(e *error) {
 	err := *e
 	s.SetAttributes(storage.ExportOtelAttributes(*s.ctx)...)
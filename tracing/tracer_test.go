@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/MikL9/observability/hide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestSpanWrapperOptions(t *testing.T) {
+	hide.SetDefaultConverter(hide.NewConverter(hide.WithFullExcludeRule([]string{"token"})))
+	defer hide.SetDefaultConverter(nil)
+	recorder := setTestTracer(t)
+
+	producerCtx, producer := otel.Tracer("test").Start(context.Background(), "produce")
+	producer.End()
+
+	ctx := context.Background()
+	span := New(&ctx, "consume_batch",
+		WithSpanKind(trace.SpanKindConsumer),
+		WithLinkFromContext(producerCtx, slog.String("messaging.message.id", "42")),
+		WithLinkFromContext(context.Background()),
+		WithAttrs(slog.Int("batch_size", 10), slog.Group("queue", slog.String("name", "orders"))),
+	)
+	span.Start()
+	span.SetAttrs(slog.String("token", "secret"))
+	span.AddEventAttrs("message skipped", slog.String("reason", "duplicate"), slog.Bool("retry", false))
+	err := errors.New("failed")
+	span.StopWrap(&err)
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+	s := ended[1]
+	assert.Equal(t, trace.SpanKindConsumer, s.SpanKind())
+	assert.Equal(t, codes.Error, s.Status().Code)
+
+	require.Len(t, s.Links(), 1)
+	assert.Equal(t, producer.SpanContext().TraceID(), s.Links()[0].SpanContext.TraceID())
+	assert.Equal(t, []attribute.KeyValue{attribute.String("messaging.message.id", "42")}, s.Links()[0].Attributes)
+
+	assert.Subset(t, s.Attributes(), []attribute.KeyValue{
+		attribute.Int64("batch_size", 10),
+		attribute.String("queue.name", "orders"),
+		attribute.String("token", "******"),
+	})
+
+	require.Len(t, s.Events(), 2)
+	assert.Equal(t, "message skipped", s.Events()[0].Name)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("reason", "duplicate"),
+		attribute.Bool("retry", false),
+	}, s.Events()[0].Attributes)
+}
+
+func TestSpanWrapperDefaultKind(t *testing.T) {
+	recorder := setTestTracer(t)
+
+	ctx := context.Background()
+	span := New(&ctx, "internal_op")
+	span.Start()
+	var err error
+	span.StopWrap(&err)
+
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, trace.SpanKindInternal, recorder.Ended()[0].SpanKind())
+}
@@ -9,6 +9,8 @@ import (
 type ResponseData struct {
 	Body   []byte
 	Status int
+	// Length полная длина тела, если Body содержит только его начало. 0 означает len(Body)
+	Length int
 }
 
 func GetRequestBodyCopy(r *http.Request) []byte {
@@ -24,6 +26,19 @@ func GetRequestBodyCopy(r *http.Request) []byte {
 	return reqBody
 }
 
+// GetRequestBodyPrefix читает не больше limit байт тела запроса и возвращает их,
+// прочитанное начало склеивается с остатком, так что тело остается доступным целиком
+func GetRequestBodyPrefix(r *http.Request, limit int) []byte {
+	if r.Body == nil {
+		return []byte{}
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(r.Body, int64(max(limit, 0))))
+	r.Body = &splicedBody{Reader: io.MultiReader(bytes.NewReader(prefix), r.Body), Closer: r.Body}
+
+	return prefix
+}
+
 func GetResponseBodyCopy(r *http.Response) []byte {
 	if r.Body == nil {
 		return []byte{}
@@ -40,3 +55,25 @@ func GetResponseBodyCopy(r *http.Response) []byte {
 
 	return reqBody
 }
+
+// GetResponseBodyPrefix читает не больше limit байт тела ответа и возвращает их,
+// прочитанное начало склеивается с остатком, так что тело остается доступным целиком
+func GetResponseBodyPrefix(r *http.Response, limit int) []byte {
+	if r.Body == nil {
+		return []byte{}
+	}
+
+	if r.Header.Get("Content-Type") == "application/pdf" {
+		return []byte("pdf file")
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	r.Body = &splicedBody{Reader: io.MultiReader(bytes.NewReader(prefix), r.Body), Closer: r.Body}
+
+	return prefix
+}
+
+type splicedBody struct {
+	io.Reader
+	io.Closer
+}
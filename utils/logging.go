@@ -36,6 +36,16 @@ const (
 // KeyRequest должен использоваться до исполнения запроса,
 // так как body будет уже прочтен и будет пустым
 func KeyRequest(r *http.Request, stringBody bool) slog.Attr {
+	var body []byte
+	if HasRequestBody(r) {
+		body = GetRequestBodyCopy(r)
+	}
+	return KeyRequestWithBody(r, body, 10*Kilobyte, stringBody)
+}
+
+// KeyRequestWithBody аналог KeyRequest для заранее прочитанного тела.
+// Тело не логируется, если body == nil или limit <= 0
+func KeyRequestWithBody(r *http.Request, body []byte, limit int, stringBody bool) slog.Attr {
 	attrs := []slog.Attr{
 		slog.String("remote_addr", GetRealIP(r)),
 		slog.String("url", r.RequestURI),
@@ -45,17 +55,35 @@ func KeyRequest(r *http.Request, stringBody bool) slog.Attr {
 		slog.Int64("body_length", r.ContentLength),
 		slog.String("method", r.Method),
 	}
-	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
-		attrs = append(attrs, hide.JSON("body", GetRequestBodyCopy(r), 10*Kilobyte, stringBody))
+	if body != nil && limit > 0 {
+		attrs = append(attrs, hide.JSON("body", body, limit, stringBody))
 	}
 	return slog.Attr{Key: "request", Value: slog.GroupValue(attrs...)}
 }
+
+// HasRequestBody сообщает, логируется ли тело запроса для его метода
+func HasRequestBody(r *http.Request) bool {
+	return r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch
+}
+
 func KeyResponse(resp *ResponseData, stringBody bool) slog.Attr {
-	return slog.Group("response",
-		slog.Int("status", resp.Status),
-		slog.Int("content_length", len(resp.Body)),
-		hide.JSON("body", resp.Body, 10*Kilobyte, stringBody),
-	)
+	return KeyResponseWithLimit(resp, 10*Kilobyte, stringBody)
+}
+
+// KeyResponseWithLimit аналог KeyResponse с ограничением размера тела, limit <= 0 - без тела
+func KeyResponseWithLimit(resp *ResponseData, limit int, stringBody bool) slog.Attr {
+	attrs := []slog.Attr{slog.Int("status", resp.Status)}
+	if limit > 0 {
+		length := resp.Length
+		if length == 0 {
+			length = len(resp.Body)
+		}
+		attrs = append(attrs,
+			slog.Int("content_length", length),
+			hide.JSON("body", resp.Body, limit, stringBody),
+		)
+	}
+	return slog.Attr{Key: "response", Value: slog.GroupValue(attrs...)}
 }
 func KeyPanic(cause string, skip int) slog.Attr {
 	pc, file, line, _ := runtime.Caller(skip + 1)